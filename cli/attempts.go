@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// retryStats sums up the reruns of a test, as done by CI setups that retry
+// failed tests and concatenate the output of every attempt.
+type retryStats struct {
+	pkg  pkgid
+	name string
+	// attempts counts every result, and retries those after the first of
+	// each run, passedRetries the retries that passed.
+	attempts      int
+	retries       int
+	passedRetries int
+	// retryTime is the time spent in retries.
+	retryTime time.Duration
+}
+
+func (r *retryStats) passOnRetryRate() float64 {
+	return float64(r.passedRetries) / float64(r.retries)
+}
+
+// retriedTests lists the tests that ran more than once within a run, those
+// that cost the most time in retries first.
+func (s *stats) retriedTests() []*retryStats {
+	seen := make(map[string]bool)
+	byTest := make(map[id]*retryStats)
+	for _, r := range s.Results {
+		if r.Kind != "test" || r.Status == "skip" {
+			continue
+		}
+		key := testjson.TestID(r.Package, r.Test)
+		rs, ok := byTest[key]
+		if !ok {
+			rs = &retryStats{pkg: r.Package, name: r.Test}
+			byTest[key] = rs
+		}
+		rs.attempts++
+		runKey := r.Run + "\n" + key
+		if !seen[runKey] {
+			seen[runKey] = true
+			continue
+		}
+		rs.retries++
+		rs.retryTime += fromSeconds(r.Duration)
+		if r.Status == "pass" {
+			rs.passedRetries++
+		}
+	}
+	var out []*retryStats
+	for _, rs := range byTest {
+		if rs.retries > 0 {
+			out = append(out, rs)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].retryTime != out[j].retryTime {
+			return out[i].retryTime > out[j].retryTime
+		}
+		return testjson.TestID(out[i].pkg, out[i].name) < testjson.TestID(out[j].pkg, out[j].name)
+	})
+	return out
+}
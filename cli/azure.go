@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	azureAPIVersion = "7.1"
+	// azureResultBatch is the number of results added per request.
+	azureResultBatch = 1000
+)
+
+type azureClient struct {
+	url     string
+	project string
+	token   string
+}
+
+func (c *azureClient) do(method, path string, in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(c.url, "/") + "/" + c.project + "/_apis/test" + path + "?api-version=" + azureAPIVersion
+	req, err := http.NewRequest(method, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("azure: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type azureResult struct {
+	TestCaseTitle        string  `json:"testCaseTitle"`
+	AutomatedTestName    string  `json:"automatedTestName"`
+	AutomatedTestStorage string  `json:"automatedTestStorage"`
+	AutomatedTestType    string  `json:"automatedTestType"`
+	Outcome              string  `json:"outcome"`
+	State                string  `json:"state"`
+	DurationInMs         float64 `json:"durationInMs"`
+	StartedDate          string  `json:"startedDate,omitempty"`
+	CompletedDate        string  `json:"completedDate,omitempty"`
+	ErrorMessage         string  `json:"errorMessage,omitempty"`
+	StackTrace           string  `json:"stackTrace,omitempty"`
+	Comment              string  `json:"comment,omitempty"`
+}
+
+var azureOutcomes = map[string]string{
+	"pass": "Passed",
+	"fail": "Failed",
+	"skip": "NotExecuted",
+}
+
+func azureResultOf(c reportCase) azureResult {
+	r := azureResult{
+		TestCaseTitle:        c.name,
+		AutomatedTestName:    c.pkg + "." + c.name,
+		AutomatedTestStorage: c.pkg,
+		AutomatedTestType:    "go test",
+		Outcome:              azureOutcomes[c.status],
+		State:                "Completed",
+		DurationInMs:         c.duration * 1000,
+	}
+	if !c.start.IsZero() {
+		r.StartedDate = c.start.UTC().Format(time.RFC3339Nano)
+		r.CompletedDate = c.end.UTC().Format(time.RFC3339Nano)
+	}
+	switch c.status {
+	case "fail":
+		r.ErrorMessage, r.StackTrace = c.message, c.details
+	case "skip":
+		r.Comment = c.message
+	}
+	return r
+}
+
+// publishRun creates a test run, adds the results to it in batches and
+// completes it.
+func (c *azureClient) publishRun(name string, build int, cases []reportCase) (int, error) {
+	in := map[string]interface{}{
+		"name":      name,
+		"automated": true,
+		"state":     "InProgress",
+	}
+	if build != 0 {
+		in["build"] = map[string]string{"id": strconv.Itoa(build)}
+	}
+	var run struct {
+		ID int `json:"id"`
+	}
+	if err := c.do(http.MethodPost, "/runs", in, &run); err != nil {
+		return 0, err
+	}
+	results := make([]azureResult, 0, len(cases))
+	for _, rc := range cases {
+		results = append(results, azureResultOf(rc))
+	}
+	for len(results) > 0 {
+		n := azureResultBatch
+		if n > len(results) {
+			n = len(results)
+		}
+		if err := c.do(http.MethodPost, fmt.Sprintf("/runs/%d/results", run.ID), results[:n], nil); err != nil {
+			return run.ID, err
+		}
+		results = results[n:]
+	}
+	return run.ID, c.do(http.MethodPatch, fmt.Sprintf("/runs/%d", run.ID), map[string]string{"state": "Completed"}, nil)
+}
+
+func publishAzure(args []string) {
+	fs := flag.NewFlagSet("publish azure", flag.ExitOnError)
+	c := &azureClient{}
+	fs.StringVar(&c.url, "url", os.Getenv("SYSTEM_COLLECTIONURI"), "Organization `url` such as https://dev.azure.com/org (default $SYSTEM_COLLECTIONURI)")
+	fs.StringVar(&c.project, "project", os.Getenv("SYSTEM_TEAMPROJECT"), "Project `name` (default $SYSTEM_TEAMPROJECT)")
+	fs.StringVar(&c.token, "token", os.Getenv("SYSTEM_ACCESSTOKEN"), "Access token (default $SYSTEM_ACCESSTOKEN)")
+	name := fs.String("name", "go test", "Test run `name`")
+	buildID, _ := strconv.Atoi(os.Getenv("BUILD_BUILDID"))
+	build := fs.Int("build", buildID, "Build `id` the test run is attached to (default $BUILD_BUILDID)")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats publish azure [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Publishes the results as a test run through the Azure DevOps Test Runs API.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if c.url == "" || c.project == "" || c.token == "" {
+		fatal("publish azure: -url, -project and -token are required")
+	}
+
+	s := newStats()
+	s.CaptureOutput = true
+	s.addFiles(fs.Args())
+
+	id, err := c.publishRun(*name, *build, reportCases(s))
+	if err != nil {
+		fatal(err)
+	}
+	logInfo("published test run", "id", id)
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// bazelTestLogs is the directory Bazel writes test results below, at the
+// path of each test target.
+const bazelTestLogs = "bazel-testlogs/"
+
+// bazelRunDir matches the directories Bazel adds below a target's test logs
+// for shards, runs and attempts.
+var bazelRunDir = regexp.MustCompile(`^(shard_\d+_of_\d+|run_\d+_of_\d+|attempt_\d+)$`)
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Time      string      `xml:"time,attr"`
+	Cases     []junitCase `xml:"testcase"`
+	SystemOut string      `xml:"system-out"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// bazelLabel returns the target label of a test.xml below bazel-testlogs,
+// such as `//a/b:c` for `bazel-testlogs/a/b/c/test.xml`.
+func bazelLabel(p string) (string, bool) {
+	p = filepath.ToSlash(p)
+	i := strings.LastIndex(p, bazelTestLogs)
+	if i < 0 {
+		return "", false
+	}
+	dir := path.Dir(p[i+len(bazelTestLogs):])
+	for bazelRunDir.MatchString(path.Base(dir)) {
+		dir = path.Dir(dir)
+	}
+	switch {
+	case dir == ".":
+		return "", false
+	case path.Dir(dir) == ".":
+		return "//:" + dir, true
+	}
+	return "//" + path.Dir(dir) + ":" + path.Base(dir), true
+}
+
+// readBazelXML converts the JUnit XML test.xml files written by Bazel into
+// test events: each test suite becomes a package, named after the target
+// label when the file is below bazel-testlogs and after the suite otherwise,
+// and each test case a test of it. Test cases are laid out one after another
+// from the suite timestamp or, without one, so that the suite ends at end.
+func readBazelXML(r io.Reader, p string, end time.Time, fn func(testjson.RawLine)) error {
+	label, hasLabel := bazelLabel(p)
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "testsuite" {
+			continue
+		}
+		var suite junitSuite
+		if err := dec.DecodeElement(&suite, &start); err != nil {
+			return err
+		}
+		pkg := suite.Name
+		if hasLabel {
+			pkg = label
+		}
+		emitJUnitSuite(suite, pkg, end, fn)
+	}
+}
+
+func emitJUnitSuite(suite junitSuite, pkg string, end time.Time, fn func(testjson.RawLine)) {
+	elapsed := parseSeconds(suite.Time)
+	if elapsed == 0 {
+		for _, c := range suite.Cases {
+			elapsed += parseSeconds(c.Time)
+		}
+	}
+	t, ok := parseJUnitTime(suite.Timestamp)
+	if !ok {
+		t = end.Add(-fromSeconds(elapsed))
+	}
+	suiteStart := t
+	output := func(test, text string) {
+		for _, l := range strings.SplitAfter(text, "\n") {
+			if l != "" {
+				fn(testjson.RawLine{Time: t, Action: "output", Package: pkg, Test: test, Output: l})
+			}
+		}
+	}
+	fn(testjson.RawLine{Time: t, Action: "start", Package: pkg})
+	failed := false
+	for _, c := range suite.Cases {
+		fn(testjson.RawLine{Time: t, Action: "run", Package: pkg, Test: c.Name})
+		d := parseSeconds(c.Time)
+		t = t.Add(fromSeconds(d))
+		output(c.Name, c.SystemOut)
+		action := "pass"
+		for _, m := range []*junitMessage{c.Failure, c.Error} {
+			if m != nil {
+				action = "fail"
+				output(c.Name, strings.TrimSpace(m.Message+"\n"+m.Text)+"\n")
+			}
+		}
+		if c.Skipped != nil && action == "pass" {
+			action = "skip"
+			if msg := strings.TrimSpace(c.Skipped.Message + "\n" + c.Skipped.Text); msg != "" {
+				output(c.Name, msg+"\n")
+			}
+		}
+		failed = failed || action == "fail"
+		fn(testjson.RawLine{Time: t, Action: action, Package: pkg, Test: c.Name, Elapsed: d})
+	}
+	output("", suite.SystemOut)
+	action := "pass"
+	if failed {
+		action = "fail"
+	}
+	if t.Before(suiteStart.Add(fromSeconds(elapsed))) {
+		t = suiteStart.Add(fromSeconds(elapsed))
+	}
+	fn(testjson.RawLine{Time: t, Action: action, Package: pkg, Elapsed: elapsed})
+}
+
+// parseJUnitTime parses suite timestamps, which JUnit writes without a time
+// zone and Bazel in RFC 3339.
+func parseJUnitTime(v string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseSeconds(v string) float64 {
+	f, _ := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+	return f
+}
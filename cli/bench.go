@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// benchmark holds the samples reported by one benchmark, one per unit such
+// as `ns/op`, `B/op` or `allocs/op`, in the order they were printed.
+type benchmark struct {
+	pkg     pkgid
+	name    string
+	units   []string
+	samples map[string][]float64
+}
+
+// recordBench parses benchmark result lines out of package output. A result
+// such as `BenchmarkX-8 \t 1000\t 236.9 ns/op\t 64 B/op` is printed across
+// several output events, and repeated runs under -count carry no Test field,
+// so output is joined per package until a full line is available.
+func (s *stats) recordBench(line testjson.RawLine) {
+	if line.Action != "output" {
+		return
+	}
+	pending := s.benchLines[line.Package] + line.Output
+	if !strings.HasSuffix(pending, "\n") {
+		s.benchLines[line.Package] = pending
+		return
+	}
+	delete(s.benchLines, line.Package)
+	name, values, ok := parseBenchLine(pending)
+	if !ok {
+		return
+	}
+	key := testjson.TestID(line.Package, name)
+	b, ok := s.benchmarks[key]
+	if !ok {
+		b = &benchmark{pkg: line.Package, name: name, samples: make(map[string][]float64)}
+		s.benchmarks[key] = b
+	}
+	for _, v := range values {
+		if _, ok := b.samples[v.unit]; !ok {
+			b.units = append(b.units, v.unit)
+		}
+		b.samples[v.unit] = append(b.samples[v.unit], v.value)
+	}
+}
+
+type benchValue struct {
+	value float64
+	unit  string
+}
+
+// parseBenchLine parses a line of the standard benchmark format: the name,
+// the iteration count, then value and unit pairs.
+func parseBenchLine(text string) (string, []benchValue, bool) {
+	fields := strings.Fields(text)
+	if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+		return "", nil, false
+	}
+	if _, err := strconv.ParseUint(fields[1], 10, 64); err != nil {
+		return "", nil, false
+	}
+	var values []benchValue
+	for i := 2; i < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return "", nil, false
+		}
+		values = append(values, benchValue{value: v, unit: fields[i+1]})
+	}
+	return fields[0], values, true
+}
+
+func (s *stats) benchmarksSorted() []*benchmark {
+	var out []*benchmark
+	for _, b := range s.benchmarks {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].pkg != out[j].pkg {
+			return out[i].pkg < out[j].pkg
+		}
+		return out[i].name < out[j].name
+	})
+	return out
+}
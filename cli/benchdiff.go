@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// benchDelta compares the samples of one benchmark unit between two runs.
+type benchDelta struct {
+	pkg  pkgid
+	name string
+	unit string
+	base []float64
+	head []float64
+	// p is the two-sided Mann-Whitney U test p-value of the difference.
+	p float64
+}
+
+func (d benchDelta) ratio() float64 {
+	base := mean(d.base)
+	if base == 0 {
+		return 0
+	}
+	return (mean(d.head) - base) / base
+}
+
+// significant reports whether the change is unlikely to be noise: its
+// p-value is at most alpha and it moved by more than threshold.
+func (d benchDelta) significant(alpha, threshold float64) bool {
+	return d.p <= alpha && math.Abs(d.ratio()) > threshold
+}
+
+// worse reports whether the change is a regression. Throughput units such as
+// `MB/s` improve upwards, every other unit downwards.
+func (d benchDelta) worse() bool {
+	if strings.HasSuffix(d.unit, "/s") {
+		return d.ratio() < 0
+	}
+	return d.ratio() > 0
+}
+
+// benchDeltas pairs the benchmark units reported in both runs.
+func benchDeltas(base, head *stats) []benchDelta {
+	var out []benchDelta
+	for _, h := range head.benchmarksSorted() {
+		b, ok := base.benchmarks[testjson.TestID(h.pkg, h.name)]
+		if !ok {
+			continue
+		}
+		for _, unit := range h.units {
+			if _, ok := b.samples[unit]; !ok {
+				continue
+			}
+			d := benchDelta{pkg: h.pkg, name: h.name, unit: unit, base: b.samples[unit], head: h.samples[unit]}
+			d.p = mannWhitneyP(d.base, d.head)
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func mean(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// spread is the largest deviation of a sample from the mean, relative to
+// the mean, as benchstat prints after ±.
+func spread(xs []float64) float64 {
+	m := mean(xs)
+	if m == 0 {
+		return 0
+	}
+	var d float64
+	for _, x := range xs {
+		d = math.Max(d, math.Abs(x-m))
+	}
+	return d / m
+}
+
+// mannWhitneyP returns the two-sided p-value of the Mann-Whitney U test that
+// a and b come from the same distribution. Like benchstat, it is exact for
+// small samples, with or without ties, and uses the tie-corrected normal
+// approximation otherwise.
+func mannWhitneyP(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	type obs struct {
+		v     float64
+		first bool
+	}
+	var all []obs
+	for _, v := range a {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range b {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Rank with ties given their average rank.
+	var rankSum, tieTerm float64
+	var groups []int
+	ties := false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		groups = append(groups, j-i)
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+	u := rankSum - float64(n1*(n1+1))/2
+
+	if !ties && n1+n2 <= 50 {
+		dist := uDistribution(n1, n2)
+		var total, below float64
+		for k, c := range dist {
+			total += c
+			if float64(k) <= u {
+				below += c
+			}
+		}
+		above := total - below + dist[int(u)]
+		return math.Min(1, 2*math.Min(below, above)/total)
+	}
+	if ties && n1+n2 <= 50 {
+		// The distribution is over twice the rank sum, which is a whole
+		// number even with average ranks.
+		dist := tiedRankSumDistribution(n1, groups)
+		r := int(2 * rankSum)
+		var total, below, above float64
+		for k, c := range dist {
+			total += c
+			if k <= r {
+				below += c
+			}
+			if k >= r {
+				above += c
+			}
+		}
+		return math.Min(1, 2*math.Min(below, above)/total)
+	}
+
+	n := float64(n1 + n2)
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieTerm/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	z := (math.Abs(u-mu) - 0.5) / sigma
+	if z < 0 {
+		return 1
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// uDistribution counts the orderings of n1 and n2 distinct samples that give
+// each value of the U statistic.
+func uDistribution(n1, n2 int) []float64 {
+	// f[i][j] is the distribution for samples of size i and j, built from
+	// f[i-1][j] (the largest value in the first sample) and f[i][j-1].
+	f := make([][][]float64, n1+1)
+	for i := range f {
+		f[i] = make([][]float64, n2+1)
+		for j := range f[i] {
+			d := make([]float64, i*j+1)
+			switch {
+			case i == 0 || j == 0:
+				d[0] = 1
+			default:
+				for k := range d {
+					if k >= j {
+						d[k] += f[i-1][j][k-j]
+					}
+					if k < len(f[i][j-1]) {
+						d[k] += f[i][j-1][k]
+					}
+				}
+			}
+			f[i][j] = d
+		}
+	}
+	return f[n1][n2]
+}
+
+// tiedRankSumDistribution counts the ways of drawing a first sample of size
+// n1 from pooled values that fall, in order, into tie groups of the given
+// sizes, by twice the rank sum of the sample. Every value in a group has the
+// group's average rank.
+func tiedRankSumDistribution(n1 int, groups []int) []float64 {
+	n := 0
+	for _, t := range groups {
+		n += t
+	}
+	// f[k][r] counts the ways of drawing k values from the groups so far
+	// with twice their rank sum r.
+	f := make([][]float64, n1+1)
+	for k := range f {
+		f[k] = make([]float64, n*(n+1)+1)
+	}
+	f[0][0] = 1
+	start := 0
+	for _, t := range groups {
+		rank2 := 2*start + t + 1
+		for k := n1; k >= 0; k-- {
+			for r := range f[k] {
+				if f[k][r] == 0 {
+					continue
+				}
+				ways := 1.0
+				for m := 1; m <= t && k+m <= n1; m++ {
+					ways = ways * float64(t-m+1) / float64(m)
+					f[k+m][r+m*rank2] += f[k][r] * ways
+				}
+			}
+		}
+		start += t
+	}
+	return f[n1]
+}
+
+// printBenchDiff writes one row per benchmark unit: the base and head means
+// with their spread, the change and the test p-value. Changes that are not
+// significant are shown as `~`.
+func printBenchDiff(w io.Writer, deltas []benchDelta, alpha, threshold float64) {
+	fmt.Fprintf(w, "name\tpkg\tunit\tbase\thead\tdelta\tp\n")
+	for _, d := range deltas {
+		change := "~"
+		if d.significant(alpha, threshold) {
+			change = fmt.Sprintf("%+.2f%%", 100*d.ratio())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t(p=%.3f n=%d+%d)\n", d.name, d.pkg, d.unit,
+			benchValueString(d.base), benchValueString(d.head), change, d.p, len(d.base), len(d.head))
+	}
+}
+
+func benchValueString(xs []float64) string {
+	return fmt.Sprintf("%.4g ±%.0f%%", mean(xs), 100*spread(xs))
+}
+
+func benchDiff(args []string) {
+	fs := flag.NewFlagSet("bench-diff", flag.ExitOnError)
+	var base fileList
+	fs.Var(&base, "base", "Comma-separated `files` with the benchmark results to compare against")
+	alpha := fs.Float64("alpha", 0.05, "Largest p-value for a change to be considered significant")
+	var threshold, failOnRegression percent
+	fs.Var(&threshold, "threshold", "Treat changes smaller than this `percentage` as noise")
+	fs.Var(&failOnRegression, "fail-on-regression", "Exit with code 3 when a benchmark significantly regresses by more than this `percentage`")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats bench-diff -base old.json [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Compares benchmark results between two runs, such as from `go test -bench . -benchmem -count 10 -json`.\n")
+		fmt.Fprintf(fs.Output(), "Each unit a benchmark reports, such as sec/op, B/op and allocs/op, is compared on a row of its own.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if len(base) == 0 {
+		fatal("bench-diff: -base is required")
+	}
+
+	deltas := benchDeltas(newStatsFromFiles(base, nil), newStatsFromFiles(fs.Args(), nil))
+	printBenchDiff(os.Stdout, deltas, *alpha, float64(threshold))
+	if failOnRegression > 0 {
+		for _, d := range deltas {
+			if d.worse() && d.significant(*alpha, math.Max(float64(threshold), float64(failOnRegression))) {
+				os.Exit(exitThresholdExceeded)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"math"
+	"testing"
+)
+
+// The p-values are those benchstat prints for the same samples, computed
+// exactly by enumerating every way of splitting the pooled values.
+func TestMannWhitneyP(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		p    float64
+	}{
+		{"n=1+1", []float64{1}, []float64{2}, 1},
+		{"n=1+1 equal", []float64{1}, []float64{1}, 1},
+		{"empty", nil, []float64{1, 2}, 1},
+		{"separated n=3+3", []float64{1, 2, 3}, []float64{4, 5, 6}, 0.1},
+		{"separated n=5+5", []float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 2.0 / 252},
+		{"reversed n=5+5", []float64{6, 7, 8, 9, 10}, []float64{1, 2, 3, 4, 5}, 2.0 / 252},
+		{"interleaved n=5+5", []float64{1, 3, 5, 7, 9}, []float64{2, 4, 6, 8, 10}, 174.0 / 252},
+		{"unequal n=3+5", []float64{1, 2, 3}, []float64{4, 5, 6, 7, 8}, 2.0 / 56},
+		{"all equal", []float64{1, 1, 1}, []float64{1, 1, 1}, 1},
+		{"ties n=5+5", []float64{1, 1, 2, 2, 3}, []float64{2, 3, 3, 4, 4}, 20.0 / 252},
+		{"tie across n=5+5", []float64{1, 2, 3, 4, 5}, []float64{3, 6, 7, 8, 9}, 10.0 / 252},
+		{"ties n=10+10", []float64{1, 1, 1, 2, 2, 2, 2, 3, 3, 3}, []float64{2, 2, 3, 3, 3, 4, 4, 4, 4, 5}, 0.006386802052436727},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := mannWhitneyP(tt.a, tt.b); math.Abs(p-tt.p) > 1e-9 {
+				t.Errorf("mannWhitneyP(%v, %v) = %v, want %v", tt.a, tt.b, p, tt.p)
+			}
+		})
+	}
+}
+
+// Samples too large to enumerate use the normal approximation.
+func TestMannWhitneyPApproximation(t *testing.T) {
+	var a, b []float64
+	for i := 0; i < 30; i++ {
+		a = append(a, float64(i/3))
+		b = append(b, float64(i/3+2))
+	}
+	// U = 288 against a mean of 450, with the continuity and tie
+	// corrections.
+	want := 0.016504477012993086
+	if p := mannWhitneyP(a, b); math.Abs(p-want) > 1e-9 {
+		t.Errorf("mannWhitneyP = %v, want %v", p, want)
+	}
+}
+
+func TestUDistribution(t *testing.T) {
+	tests := []struct {
+		n1, n2 int
+		want   []float64
+	}{
+		{1, 1, []float64{1, 1}},
+		{1, 3, []float64{1, 1, 1, 1}},
+		{2, 2, []float64{1, 1, 2, 1, 1}},
+		{2, 3, []float64{1, 1, 2, 2, 2, 1, 1}},
+		{3, 3, []float64{1, 1, 2, 3, 3, 3, 3, 2, 1, 1}},
+	}
+	for _, tt := range tests {
+		got := uDistribution(tt.n1, tt.n2)
+		if len(got) != len(tt.want) {
+			t.Errorf("uDistribution(%d, %d) = %v, want %v", tt.n1, tt.n2, got, tt.want)
+			continue
+		}
+		for k := range got {
+			if got[k] != tt.want[k] {
+				t.Errorf("uDistribution(%d, %d) = %v, want %v", tt.n1, tt.n2, got, tt.want)
+				break
+			}
+		}
+	}
+}
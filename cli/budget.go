@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+type budgetUse struct {
+	pkg    pkgid
+	actual time.Duration
+	budget time.Duration
+}
+
+func (b budgetUse) utilization() float64 {
+	if b.budget == 0 {
+		return 0
+	}
+	return float64(b.actual) / float64(b.budget)
+}
+
+// budgetUsesSortedByUtilizationDescending compares every package with a
+// configured budget to its duration. Budgeted packages missing from the run
+// are listed with no time spent.
+func (s *stats) budgetUsesSortedByUtilizationDescending(budgets map[pkgid]time.Duration) []budgetUse {
+	var out []budgetUse
+	for id, budget := range budgets {
+		u := budgetUse{pkg: id, budget: budget}
+		if p, ok := s.Packages[id]; ok {
+			u.actual = p.Duration
+		}
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].utilization() != out[j].utilization() {
+			return out[j].utilization() < out[i].utilization()
+		}
+		return out[i].pkg < out[j].pkg
+	})
+	return out
+}
+
+func printBudgets(w io.Writer, s *stats, opts options) error {
+	if len(opts.budgets) == 0 {
+		return fmt.Errorf("the budget statistic needs package budgets in the config file")
+	}
+	for _, u := range s.budgetUsesSortedByUtilizationDescending(opts.budgets) {
+		status, color := "ok", colorGreen
+		if u.actual > u.budget {
+			status, color = "over", colorRed
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%.1f%%\t%s", u.pkg, opts.dur(u.actual), opts.dur(u.budget), 100*u.utilization(), status)
+		fmt.Fprintln(w, opts.paint(color, line))
+	}
+	return nil
+}
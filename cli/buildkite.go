@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+const (
+	// buildkiteTop is the number of slowest tests listed.
+	buildkiteTop = 10
+	// buildkiteOutputLines is the number of trailing output lines shown per
+	// failure, keeping annotations under Buildkite's size limit.
+	buildkiteOutputLines = 50
+)
+
+// buildkiteReport summarizes a run as annotation Markdown: build failures
+// and failed tests with their output, flaky candidates and the slowest
+// tests. It returns the annotation style matching the outcome.
+func buildkiteReport(s *stats, opts options) (string, string) {
+	var b strings.Builder
+	tests := s.TestsByDuration()
+	var failed, flaky []*testjson.Test
+	for _, t := range tests {
+		if !t.Passed {
+			failed = append(failed, t)
+		}
+		if t.Flaky() {
+			flaky = append(flaky, t)
+		}
+	}
+	byName := func(ts []*testjson.Test) {
+		sort.Slice(ts, func(i, j int) bool {
+			return testjson.TestID(ts[i].Package, ts[i].Name) < testjson.TestID(ts[j].Package, ts[j].Name)
+		})
+	}
+	byName(failed)
+	byName(flaky)
+	builds := s.BuildFailuresByPackage()
+
+	style := "success"
+	switch {
+	case len(failed) > 0 || len(builds) > 0:
+		style = "error"
+	case len(flaky) > 0:
+		style = "warning"
+	}
+
+	fmt.Fprintf(&b, "**%d tests** in %d packages, **%d failed**, %d flaky\n\n", len(tests), len(s.Packages), len(failed), len(flaky))
+
+	if len(builds) > 0 {
+		fmt.Fprintf(&b, "### Build failures\n\n")
+		for _, bf := range builds {
+			writeBuildkiteDetails(&b, fmt.Sprintf("<code>%s</code> (%s)", bf.Package, bf.Kind), bf.Output)
+		}
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(&b, "### Failures\n\n")
+		for _, t := range failed {
+			summary := fmt.Sprintf("<code>%s</code> in <code>%s</code> (%s)", t.Name, t.Package, opts.dur(t.Duration))
+			writeBuildkiteDetails(&b, summary, s.Outputs[testjson.TestID(t.Package, t.Name)])
+		}
+	}
+
+	if len(flaky) > 0 {
+		fmt.Fprintf(&b, "### Flaky candidates\n\n| Test | Package | Passes | Failures |\n| --- | --- | ---: | ---: |\n")
+		for _, t := range flaky {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %d | %d |\n", t.Name, t.Package, t.Passes, t.Failures)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	if len(tests) > buildkiteTop {
+		tests = tests[:buildkiteTop]
+	}
+	if len(tests) > 0 {
+		fmt.Fprintf(&b, "### Slowest tests\n\n| Test | Package | Duration |\n| --- | --- | ---: |\n")
+		for _, t := range tests {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s |\n", t.Name, t.Package, opts.dur(t.Duration))
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+	return b.String(), style
+}
+
+// writeBuildkiteDetails writes a collapsed section with the tail of output
+// in a `term` block, which Buildkite renders with ANSI colors.
+func writeBuildkiteDetails(b *strings.Builder, summary string, output []string) {
+	fmt.Fprintf(b, "<details>\n<summary>%s</summary>\n\n", summary)
+	if len(output) > buildkiteOutputLines {
+		fmt.Fprintf(b, "Last %d of %d lines:\n\n", buildkiteOutputLines, len(output))
+		output = output[len(output)-buildkiteOutputLines:]
+	}
+	if len(output) > 0 {
+		fmt.Fprintf(b, "```term\n%s\n```\n\n", strings.Join(output, "\n"))
+	}
+	fmt.Fprintf(b, "</details>\n\n")
+}
+
+// writeBuildkite writes the annotation Markdown, or with -buildkite-annotate
+// passes it to `buildkite-agent annotate` instead.
+func writeBuildkite(w io.Writer, s *stats, opts options) error {
+	report, style := buildkiteReport(s, opts)
+	if !opts.buildkiteAnnotate {
+		_, err := io.WriteString(w, report)
+		return err
+	}
+	cmd := exec.Command("buildkite-agent", "annotate", "--style", style, "--context", "goteststats")
+	cmd.Stdin = strings.NewReader(report)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("buildkite-agent annotate: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// cacheVersion is bumped whenever RawLine or the cache layout changes, so
+// that stale entries are ignored.
+const cacheVersion = 2
+
+// cacheRecord is one element of the zstd-compressed gob stream a cache entry
+// holds: a version header, then batches of events, then a final record with
+// the format of the file and the number of skipped lines.
+type cacheRecord struct {
+	Version int
+	Lines   []testjson.RawLine
+	Format  string
+	Skipped int
+	End     bool
+}
+
+// defaultCacheDir is where parsed files are cached unless -cache-dir is
+// given.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "goteststats")
+}
+
+// cacheKey hashes the content of a file, so that cache entries survive the
+// file being moved and are never reused for a file that changed. JUnit XML
+// is converted using its path, for Bazel target labels, and modification
+// time, for suites without a timestamp, so both are hashed along.
+func cacheKey(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	format, err := sniffFile(path)
+	if err != nil {
+		return "", err
+	}
+	if format == formatJUnit {
+		info, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "\x00%s\x00%d", abs, info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readFileCached is readFile backed by a cache of parsed events in dir,
+// which decode much faster than JSON. Remote inputs are not cached.
+func readFileCached(path string, strict bool, dir string, fn func(testjson.RawLine)) (fileSummary, error) {
+	if dir == "" || isRemote(path) {
+		return readFile(path, strict, fn)
+	}
+	key, err := cacheKey(path)
+	if err != nil {
+		return fileSummary{}, err
+	}
+	// Strict reads fail on the lines lenient ones skip, so they do not
+	// share entries.
+	if strict {
+		key += "-strict"
+	}
+	entry := filepath.Join(dir, key+".gob.zst")
+	sum, ok, err := readCache(entry, fn)
+	if err != nil {
+		return sum, err
+	}
+	if ok {
+		logDebug("cache hit", "file", path, "entry", entry)
+		return sum, nil
+	}
+	logDebug("cache miss", "file", path, "entry", entry)
+
+	// Caching is best effort: on failure the events are still read.
+	var enc *gob.Encoder
+	var zw *zstd.Encoder
+	tmp, err := createCacheTemp(dir)
+	if err == nil {
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		zw, err = zstd.NewWriter(tmp, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	}
+	if err != nil {
+		logDebug("not caching", "file", path, "err", err)
+	} else {
+		defer zw.Close()
+		enc = gob.NewEncoder(zw)
+		if enc.Encode(cacheRecord{Version: cacheVersion}) != nil {
+			enc = nil
+		}
+	}
+	var batch []testjson.RawLine
+	flush := func() {
+		if enc != nil && enc.Encode(cacheRecord{Lines: batch}) != nil {
+			enc = nil
+		}
+		batch = nil
+	}
+	sum, err = readFile(path, strict, func(line testjson.RawLine) {
+		fn(line)
+		batch = append(batch, line)
+		if len(batch) == readBatch {
+			flush()
+		}
+	})
+	if err != nil {
+		return sum, err
+	}
+	flush()
+	if enc != nil && enc.Encode(cacheRecord{Format: sum.format, Skipped: sum.skipped, End: true}) == nil && zw.Close() == nil && tmp.Close() == nil {
+		os.Rename(tmp.Name(), entry)
+	}
+	return sum, nil
+}
+
+func createCacheTemp(dir string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(dir, ".tmp-*")
+}
+
+// readCache replays a cache entry, reporting false if there is no usable
+// one. Entries are renamed into place once complete, so an entry failing to
+// decode past its header is corrupt rather than partially written.
+func readCache(entry string, fn func(testjson.RawLine)) (sum fileSummary, ok bool, err error) {
+	f, err := os.Open(entry)
+	if err != nil {
+		return sum, false, nil
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return sum, false, nil
+	}
+	defer zr.Close()
+	dec := gob.NewDecoder(zr)
+	var r cacheRecord
+	if err := dec.Decode(&r); err != nil || r.Version != cacheVersion {
+		return sum, false, nil
+	}
+	for {
+		var r cacheRecord
+		if err := dec.Decode(&r); err != nil {
+			return sum, false, fmt.Errorf("corrupt cache entry %s, remove it to re-parse: %v", entry, err)
+		}
+		for _, line := range r.Lines {
+			fn(line)
+		}
+		if r.End {
+			return fileSummary{format: r.Format, skipped: r.Skipped}, true, nil
+		}
+	}
+}
@@ -0,0 +1,929 @@
+// Package cli is the goteststats command. Programs can register their own
+// statistics with testjson.RegisterStatistic and then call Main, to get a
+// goteststats that computes them alongside the built-in ones.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+type id = string
+
+type pkgid = string
+
+type stats struct {
+	// Stats holds the package and test results the other fields add to.
+	*testjson.Stats
+
+	fuzz   map[id]*fuzzTarget
+	filter *filter
+
+	read readOptions
+	// labels name the runs read, by default after their file, and inputs
+	// describes the files read.
+	labels runLabels
+	inputs []*inputFile
+	// git, when set, tags the runs read with the commit they tested.
+	git *gitTags
+	// dims, when set, labels the runs read along dimensions such as OS.
+	dims *dimensions
+	// redactor, when set, replaces names in events as they are added.
+	redactor *redactor
+
+	crashes []*crash
+	running map[pkgid][]string
+	crashed map[pkgid]bool
+
+	races      map[string]*race
+	raceBlocks map[pkgid]*raceBlock
+
+	pkgOutput  map[pkgid]*outputSize
+	testOutput map[id]*outputSize
+
+	// skipReasons holds the message each skipped test gave, taken from the
+	// latest message logged by each running test in lastLog.
+	skipReasons map[id]string
+	lastLog     map[id]string
+
+	// benchmarks holds benchmark results; benchLines buffers the partial
+	// output line of each package until it is complete.
+	benchmarks map[id]*benchmark
+	benchLines map[pkgid]string
+	// benchTimes holds the time of each benchmark, timed from its run
+	// event while it is one of openBenchmarks.
+	benchTimes     map[id]time.Duration
+	openBenchmarks map[pkgid]openBenchmark
+
+	// leaks holds leak detector reports by kind and test, and openLeaks the
+	// reports printed outside tests while their culprit is looked for.
+	leaks     map[string]*leak
+	openLeaks map[pkgid]*leak
+
+	// lintStates follows every test to find the lintIssues reported by the
+	// `lint` statistic.
+	lintStates map[id]*lintState
+	lintIssues map[string]*lintIssue
+
+	// gaps holds the packages that ran no tests, counting the test events
+	// of each package run in pkgTestEvents.
+	gaps          map[pkgid]string
+	pkgTestEvents map[pkgid]int
+}
+
+func newStats() *stats {
+	return &stats{
+		Stats:      testjson.NewStats(),
+		fuzz:       make(map[id]*fuzzTarget),
+		running:    make(map[pkgid][]string),
+		crashed:    make(map[pkgid]bool),
+		races:      make(map[string]*race),
+		raceBlocks: make(map[pkgid]*raceBlock),
+
+		pkgOutput:  make(map[pkgid]*outputSize),
+		testOutput: make(map[id]*outputSize),
+
+		skipReasons: make(map[id]string),
+		lastLog:     make(map[id]string),
+
+		benchmarks: make(map[id]*benchmark),
+		benchLines: make(map[pkgid]string),
+
+		benchTimes:     make(map[id]time.Duration),
+		openBenchmarks: make(map[pkgid]openBenchmark),
+
+		leaks:     make(map[string]*leak),
+		openLeaks: make(map[pkgid]*leak),
+
+		lintStates: make(map[id]*lintState),
+		lintIssues: make(map[string]*lintIssue),
+
+		gaps:          make(map[pkgid]string),
+		pkgTestEvents: make(map[pkgid]int),
+	}
+}
+
+// add accumulates a single event into the statistics.
+func (s *stats) add(line testjson.RawLine) {
+	if line.ImportPath != "" && line.Package == "" {
+		if s.redactor != nil {
+			line = s.redactor.line(line)
+		}
+		s.Stats.Add(line)
+		return
+	}
+	var time0 time.Time
+	isValid := line.Time.After(time0) && line.Package != "" && line.Action != ""
+	if !isValid || s.filter.excludes(line) {
+		return
+	}
+	// Redacted after filtering, so that filters refer to the real names.
+	if s.redactor != nil {
+		line = s.redactor.line(line)
+	}
+	s.recordFuzz(line)
+	s.recordRace(line)
+	s.recordCrash(line)
+	s.recordOutputSize(line)
+	s.recordSkip(line)
+	s.recordBench(line)
+	s.recordBenchTime(line)
+	s.recordLint(line)
+	s.recordGap(line)
+	s.recordLeak(line)
+	s.Stats.Add(line)
+}
+
+func newStatsFromFiles(files []string, f *filter) *stats {
+	s := newStats()
+	s.filter = f
+	s.addFiles(files)
+	return s
+}
+
+func (s *stats) addFiles(args []string) {
+	start := time.Now()
+	files, err := expandInputs(args)
+	if err != nil {
+		fatal(err)
+	}
+	events := 0
+	read := s.read
+	read.onFile = func(path string, sum fileSummary, n int) {
+		s.inputs = append(s.inputs, &inputFile{path: path, fileSummary: sum, events: n})
+	}
+	err = readFiles(files, read, func(path string, line testjson.RawLine) {
+		events++
+		s.Tags = s.tagsOf(path)
+		s.add(line)
+	})
+	if err != nil {
+		fatal(err)
+	}
+	logInfo("read inputs", "files", len(files), "events", events, "elapsed", time.Since(start))
+}
+
+// tagsOf returns the tags of the results read from path.
+func (s *stats) tagsOf(path string) testjson.Tags {
+	tags := testjson.Tags{Run: s.labels.of(path)}
+	if s.git != nil {
+		meta := s.git.of(path)
+		tags.Commit, tags.Branch, tags.CommitTime = meta.commit, meta.branch, meta.time
+	}
+	if s.dims != nil {
+		tags.Labels = s.dims.of(path)
+	}
+	return tags
+}
+
+// readOptions controls how readFiles parses its input.
+type readOptions struct {
+	// strict makes unparseable input lines an error instead of skipping them.
+	strict bool
+	// normalizeTime shifts the timestamps of every file so that its first
+	// event happens at normalizedEpoch, which lines up runs recorded on
+	// machines whose clocks disagree.
+	normalizeTime bool
+	// cacheDir, when set, caches parsed files there.
+	cacheDir string
+	// onFile, when set, is called with the summary of each file once read.
+	onFile func(path string, sum fileSummary, events int)
+}
+
+// normalizedEpoch is the time normalized runs start at.
+var normalizedEpoch = time.Unix(0, 0).UTC()
+
+// readBatch is the number of events a worker hands over at a time.
+const readBatch = 256
+
+// readFiles parses files concurrently with at most one worker per CPU, and
+// calls fn for every event, file by file in the order given, so that
+// accumulated statistics do not depend on scheduling. Workers ahead of the
+// file being consumed block once a few batches are buffered, which keeps
+// memory bounded regardless of input size. Skipped lines are logged per
+// file.
+func readFiles(files []string, opts readOptions, fn func(path string, line testjson.RawLine)) error {
+	type result struct {
+		events  int
+		sum     fileSummary
+		elapsed time.Duration
+		err     error
+	}
+	batches := make([]chan []testjson.RawLine, len(files))
+	results := make([]chan result, len(files))
+	for i := range files {
+		batches[i] = make(chan []testjson.RawLine, 16)
+		results[i] = make(chan result, 1)
+	}
+	slots := make(chan struct{}, runtime.NumCPU())
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i, path := range files {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+			go func(i int, path string) {
+				defer close(batches[i])
+				var batch []testjson.RawLine
+				flush := func() {
+					select {
+					case batches[i] <- batch:
+					case <-done:
+					}
+					batch = nil
+				}
+				start, events := time.Now(), 0
+				sum, err := readFileCached(path, opts.strict, opts.cacheDir, func(line testjson.RawLine) {
+					events++
+					batch = append(batch, line)
+					if len(batch) == readBatch {
+						flush()
+					}
+				})
+				if len(batch) > 0 {
+					flush()
+				}
+				results[i] <- result{events, sum, time.Since(start), err}
+			}(i, path)
+		}
+	}()
+	for i, path := range files {
+		var first time.Time
+		for batch := range batches[i] {
+			for _, line := range batch {
+				if opts.normalizeTime && !line.Time.IsZero() {
+					if first.IsZero() {
+						first = line.Time
+					}
+					line.Time = normalizedEpoch.Add(line.Time.Sub(first))
+				}
+				fn(path, line)
+			}
+		}
+		r := <-results[i]
+		<-slots
+		if r.err != nil {
+			return fmt.Errorf("%s: %v", path, r.err)
+		}
+		switch {
+		case r.sum.format == formatUnknown:
+			logWarn("skipped file of unknown format", "file", path)
+		case r.sum.skipped > 0:
+			logWarn("skipped unparseable lines, use -strict to fail instead", "file", path, "lines", r.sum.skipped)
+		}
+		logInfo("read file", "file", path, "format", r.sum.format, "events", r.events, "elapsed", r.elapsed)
+		if opts.onFile != nil {
+			opts.onFile(path, r.sum, r.events)
+		}
+	}
+	return nil
+}
+
+// statistics lists the built-in values accepted by the `-statistic` flag.
+var statistics = []string{"pkg-time", "test-time", "fuzz", "coverage", "critical-path", "crashes", "races", "build-failures", "output-size", "pkg-count", "overhead", "start-latency", "owners", "diff", "budget", "subtests", "skip-reasons", "mismatch", "lint", "matrix", "outliers", "trend", "coverage-gaps", "compare-by", "near-timeout", "heatmap", "kind-summary", "attempts", "leaks", "inputs"}
+
+// formats lists the values accepted by the `-format` flag.
+var formats = []string{"text", "prom", "gha", "svg-timeline", "flamegraph", "jsonl", "compact", "template", "tap", "teamcity", "buildkite", "heatmap-html"}
+
+func isFormat(name string) bool {
+	for _, f := range formats {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isStatistic(name string) bool {
+	for _, s := range statisticNames() {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+type options struct {
+	statistic     string
+	format        string
+	coverProfile  string
+	pkgFilter     string
+	testFilter    string
+	kind          string
+	testOver      time.Duration
+	pkgOver       time.Duration
+	slowThreshold time.Duration
+	slots         int
+	follow        bool
+	interval      time.Duration
+	budgets       map[pkgid]time.Duration
+	ignore        []string
+	pushgateway   string
+	// buildkiteAnnotate passes `-format buildkite` output to
+	// buildkite-agent annotate.
+	buildkiteAnnotate bool
+	strict            bool
+	normalizeTime     bool
+	// deterministic makes identical inputs produce byte-identical output.
+	deterministic  bool
+	runLabels      runLabels
+	includeCached  bool
+	includeNoTests bool
+	cache          bool
+	cacheDir       string
+	templateFile   string
+	colorMode      string
+	color          bool
+	sort           []sortKey
+	groupBy        string
+	durationFormat string
+	summary        bool
+	redact         bool
+	redactMap      string
+	owners         string
+
+	// outlierK and outlierMethod configure the `outliers` statistic.
+	outlierK      float64
+	outlierMethod string
+
+	// git tags runs with commits, and since and until limit the `trend`
+	// statistic to commits in a date range.
+	git       gitTags
+	detectGit bool
+	since     dateFlag
+	until     dateFlag
+
+	// heatmapBy colors the `heatmap` statistic and format by duration or
+	// status.
+	heatmapBy string
+
+	// dims labels runs and compareBy is the label key the `compare-by`
+	// statistic contrasts.
+	dims      dimensions
+	compareBy string
+
+	// base is the baseline run read from -baseline, if any.
+	baseline         fileList
+	base             *stats
+	failOnRegression percent
+	regressionFloor  time.Duration
+
+	// timeout is the go test -timeout of the run and nearTimeout the share
+	// of it the `near-timeout` statistic reports durations above.
+	timeout     time.Duration
+	nearTimeout percent
+
+	// failOnTestFailure exits non-zero when any package or test failed.
+	failOnTestFailure bool
+}
+
+func (o options) readOptions() readOptions {
+	r := readOptions{strict: o.strict, normalizeTime: o.normalizeTime}
+	if o.cache {
+		r.cacheDir = o.cacheDir
+	}
+	return r
+}
+
+func printStatistic(w io.Writer, s *stats, opts options) error {
+	switch opts.statistic {
+	case "pkg-time":
+		pkgdurs := s.PackagesByDuration()
+		if opts.includeNoTests {
+			pkgdurs = append(pkgdurs, s.noTestPackagesSorted()...)
+		}
+		sortPackages(pkgdurs, opts.sort)
+		for _, pkgdur := range pkgdurs {
+			line := fmt.Sprintf("%s\t%s", pkgdur.ID, opts.dur(pkgdur.Duration))
+			if pkgdur.NoTests {
+				fmt.Fprintln(w, line+"\t"+testjson.StatusNoTests)
+				continue
+			}
+			fmt.Fprintln(w, opts.paint(opts.statusColor(pkgdur.Passed, 0), line))
+		}
+	case "test-time":
+		tests := s.TestsByDuration()
+		sortTests(tests, opts.sort)
+		if opts.groupBy == "pkg" {
+			printTestsByPackage(w, tests, opts)
+			break
+		}
+		for _, t := range tests {
+			line := fmt.Sprintf("%s\t%s\t%s\t%s", t.Name, t.Package, opts.dur(t.Duration), statusOf(t.Passed))
+			fmt.Fprintln(w, opts.paint(opts.statusColor(t.Passed, t.Duration), line))
+		}
+	case "fuzz":
+		for _, f := range s.fuzzTargetsSortedByDurationDescending() {
+			crashers := "-"
+			if len(f.crashers) > 0 {
+				crashers = strings.Join(f.crashers, ",")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", f.name, f.pkg, opts.dur(f.duration), f.seeds, crashers)
+		}
+	case "coverage":
+		profile, err := readCoverProfile(opts.coverProfile)
+		if err != nil {
+			return err
+		}
+		for _, c := range s.coverageSortedByDurationDescending(profile) {
+			roi := "ok"
+			if c.lowROI {
+				roi = "low-roi"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d/%d\t%.1f%%\t%s\n", c.id, opts.dur(c.duration), c.covered, c.statements, 100*c.ratio(), roi)
+		}
+	case "critical-path":
+		printCriticalPath(w, s, opts)
+	case "crashes":
+		for _, c := range s.crashes {
+			running := "-"
+			if len(c.running) > 0 {
+				running = strings.Join(c.running, ",")
+			}
+			line := fmt.Sprintf("%s\t%s\t%s\t%s", c.kind, c.pkg, running, c.message)
+			fmt.Fprintln(w, opts.paint(colorRed, line))
+		}
+	case "build-failures":
+		for _, b := range s.BuildFailuresByPackage() {
+			fmt.Fprintln(w, opts.paint(colorRed, b.Package+"\t"+b.Kind))
+			for _, l := range b.Output {
+				fmt.Fprintf(w, "    %s\n", l)
+			}
+		}
+	case "output-size":
+		for _, o := range outputSizesSortedByBytesDescending(s.pkgOutput) {
+			fmt.Fprintf(w, "pkg\t%s\t%d\t%d\n", o.pkg, o.bytes, o.lines)
+		}
+		for _, o := range outputSizesSortedByBytesDescending(s.testOutput) {
+			fmt.Fprintf(w, "test\t%s\t%s\t%d\t%d\n", o.name, o.pkg, o.bytes, o.lines)
+		}
+	case "pkg-count":
+		for _, c := range s.pkgCountsSortedByTestsDescending() {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", c.id, c.tests, c.subtests, opts.dur(c.average()), opts.dur(c.duration))
+		}
+	case "overhead":
+		for _, c := range s.pkgCountsSortedByOverheadDescending() {
+			share := 0.0
+			if c.duration > 0 {
+				share = float64(c.overhead()) / float64(c.duration)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.1f%%\n", c.id, opts.dur(c.duration), opts.dur(c.total), opts.dur(c.overhead()), 100*share)
+		}
+	case "start-latency":
+		for _, l := range s.startLatenciesSortedDescending() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", l.test.Name, l.test.Package, opts.dur(l.latency), opts.dur(l.test.Duration))
+		}
+	case "owners":
+		p, err := ownersFile(opts.owners)
+		if err != nil {
+			return err
+		}
+		o, err := readOwners(p)
+		if err != nil {
+			return err
+		}
+		for _, st := range s.ownerStatsSortedByDurationDescending(o) {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", st.owner, opts.dur(st.duration), st.tests, st.failures, st.flakes)
+		}
+	case "diff":
+		printDiff(w, s, opts)
+	case "budget":
+		return printBudgets(w, s, opts)
+	case "skip-reasons":
+		for _, r := range s.skipReasonsSortedByCountDescending() {
+			fmt.Fprintf(w, "%s\t%d\n", r.reason, len(r.tests))
+			for _, t := range r.tests {
+				fmt.Fprintf(w, "    %s\t%s\n", t.Name, t.Package)
+			}
+		}
+	case "mismatch":
+		for _, m := range s.Mismatches {
+			kind, name := "test", m.Test
+			if name == "" {
+				kind, name = "pkg", m.Package
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", kind, name, m.Package, opts.dur(m.Elapsed), opts.dur(m.Measured))
+		}
+	case "subtests":
+		for _, g := range s.subtestGroupsSortedByTotalDescending() {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", g.parent, g.pkg, g.cases, opts.dur(g.total), opts.dur(g.average()), g.slowest.Name, opts.dur(g.slowest.Duration))
+		}
+	case "matrix":
+		printMatrix(w, s, opts)
+	case "outliers":
+		for _, o := range s.outliers(opts.outlierMethod, opts.outlierK) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%+.1f\t%d\n", o.test, o.pkg, opts.dur(o.latest), opts.dur(o.center), o.score, o.samples)
+		}
+	case "trend":
+		printTrend(w, s, opts)
+	case "compare-by":
+		printComparison(w, s, opts)
+	case "heatmap":
+		printHeatmap(w, s, opts)
+	case "attempts":
+		for _, r := range s.retriedTests() {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%.0f%%\t%s\n", r.name, r.pkg, r.attempts, 100*r.passOnRetryRate(), opts.dur(r.retryTime))
+		}
+	case "inputs":
+		printInputs(w, s)
+	case "leaks":
+		for _, l := range s.leaksSorted() {
+			test := l.test
+			if test == "" {
+				test = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", l.kind, test, l.pkg, l.count, l.message)
+		}
+	case "kind-summary":
+		for _, k := range s.kindSummaries() {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", k.kind, k.count, opts.dur(k.total))
+		}
+	case "near-timeout":
+		for _, n := range s.nearTimeouts(opts.timeout, float64(opts.nearTimeout)) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%.0f%%\n", n.kind, n.name, n.pkg, opts.dur(n.duration), opts.dur(n.timeout), 100*n.fraction())
+		}
+	case "coverage-gaps":
+		for _, g := range s.coverageGapsSorted() {
+			fmt.Fprintf(w, "%s\t%s\n", g.pkg, g.reason)
+		}
+	case "lint":
+		issues := s.lintIssuesByKind()
+		for _, kind := range lintKinds {
+			if len(issues[kind]) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%d\n", kind, len(issues[kind]))
+			for _, issue := range issues[kind] {
+				fmt.Fprintf(w, "    %s\t%s\t%d\n", issue.test, issue.pkg, issue.count)
+			}
+		}
+	case "races":
+		for _, r := range s.racesSortedByCountDescending() {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", r.test, r.pkg, r.count)
+			for _, l := range r.excerpt {
+				fmt.Fprintf(w, "%s\n", strings.TrimRight("    "+l, " "))
+			}
+		}
+	default:
+		if st := s.Statistic(opts.statistic); st != nil {
+			return st.Report(w, opts.format)
+		}
+		return runPlugin(w, opts.statistic, s)
+	}
+	return nil
+}
+
+// render writes the run in the selected format. The text format prints the
+// selected statistic; the other formats describe the whole run.
+func render(w io.Writer, s *stats, opts options) error {
+	switch opts.format {
+	case "gha":
+		return writeGitHubActions(w, s, opts)
+	case "svg-timeline":
+		return writeTimeline(w, s, opts)
+	case "flamegraph":
+		return writeFlamegraph(w, s)
+	case "jsonl":
+		return writeJSONL(w, s)
+	case "compact":
+		return writeCompact(w, s, opts)
+	case "tap":
+		return writeTAP(w, s)
+	case "teamcity":
+		return writeTeamCity(w, s)
+	case "buildkite":
+		return writeBuildkite(w, s, opts)
+	case "heatmap-html":
+		return writeHeatmapHTML(w, s, opts)
+	case "template":
+		return writeTemplate(w, s, opts)
+	case "prom":
+		if opts.pushgateway != "" {
+			return pushPrometheus(opts.pushgateway, s)
+		}
+		return writePrometheus(w, s)
+	default:
+		if opts.statistic != "" {
+			if err := printStatistic(w, s, opts); err != nil {
+				return err
+			}
+		}
+		if opts.summary {
+			printSummary(w, s, opts)
+		}
+		return nil
+	}
+}
+
+// subcommands are dispatched on the first argument, each with its own flags.
+var subcommands = map[string]func(args []string){
+	"bench-diff":   benchDiff,
+	"digest":       digest,
+	"otel-export":  otelExport,
+	"merge":        merge,
+	"notify":       notify,
+	"publish":      publish,
+	"quarantine":   quarantine,
+	"run":          runTests,
+	"schema":       schema,
+	"serve":        serve,
+	"shard-plan":   shardPlan,
+	"tui":          runTUI,
+	"what-changed": whatChangedCommand,
+}
+
+func subcommandNames() []string {
+	var names []string
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Main runs goteststats with the command line arguments, along with every
+// statistic registered through testjson.RegisterStatistic beforehand.
+func Main() {
+	checkRegisteredStatistics()
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
+
+	var opts options
+	var configPath string
+	flag.StringVar(&opts.statistic, "statistic", "", "Statistic to compute: "+strings.Join(statisticNames(), "|")+", or any other for which a "+pluginPrefix+"<statistic> plugin on PATH is given the -format jsonl records on stdin")
+	flag.StringVar(&opts.format, "format", "text", "Output format: "+strings.Join(formats, "|"))
+	flag.StringVar(&configPath, "config", "", "Config `file` with default settings (default: nearest .goteststats.yaml up to the repository root)")
+	flag.StringVar(&opts.pkgFilter, "pkg-filter", "", "Only include packages matching this `regexp`")
+	flag.StringVar(&opts.testFilter, "test-filter", "", "Only include tests matching this `regexp`")
+	flag.StringVar(&opts.kind, "kind", "", "Only include tests of these comma-separated `kinds`: "+strings.Join(kindNames(), "|"))
+	flag.StringVar(&opts.coverProfile, "coverprofile", "", "Go coverage profile `file` to correlate with test time (used by the coverage statistic)")
+	flag.StringVar(&opts.templateFile, "template-file", "", "Go text/template `file` to render with `-format template`")
+	flag.StringVar(&opts.pushgateway, "pushgateway", "", "Push `url` of a Prometheus Pushgateway to send `-format prom` metrics to instead of printing them")
+	flag.BoolVar(&opts.buildkiteAnnotate, "buildkite-annotate", false, "Annotate the build with -format buildkite output through buildkite-agent instead of printing it")
+	flag.DurationVar(&opts.slowThreshold, "slow-threshold", 0, "Highlight tests taking longer than this duration as slow")
+	flag.DurationVar(&opts.testOver, "fail-if-test-over", 0, "Exit non-zero if any test takes longer than this duration")
+	flag.DurationVar(&opts.pkgOver, "fail-if-pkg-over", 0, "Exit non-zero if any package takes longer than this duration")
+	flag.IntVar(&opts.slots, "p", 0, "Number of parallel `slots` the run used (go test -p), for critical-path utilization (default: peak observed concurrency)")
+	flag.BoolVar(&opts.follow, "follow", false, "Follow a single file (or stdin as -) while it is written and periodically re-render")
+	flag.DurationVar(&opts.interval, "interval", 2*time.Second, "Re-render interval for -follow")
+	flag.StringVar(&opts.colorMode, "color", "auto", "Color text output by status: "+strings.Join(colorModes, "|"))
+	var sortSpec string
+	flag.StringVar(&sortSpec, "sort", "", "Comma-separated sort `keys` for pkg-time and test-time, each of "+strings.Join(sortFields, "|")+" with an optional :asc or :desc (default duration:desc)")
+	flag.StringVar(&opts.groupBy, "group-by", "", "Group test-time output by `key` with subtotals: "+strings.Join(groupings, "|"))
+	flag.StringVar(&opts.durationFormat, "duration-format", "go", "Duration `format` in reports: "+strings.Join(durationFormats, "|")+" (seconds, milliseconds, or rounded); the jsonl, prom, tap, teamcity and flamegraph formats keep the fixed units their consumers parse")
+	flag.BoolVar(&opts.summary, "summary", false, "Append run totals (tests, passed, failed, build failures, skipped, packages, duration, slowest test) to text output")
+	flag.BoolVar(&opts.redact, "redact", false, "Replace package paths and test names with opaque identifiers, keyed per run, for sharing reports")
+	flag.StringVar(&opts.redactMap, "redact-map", "", "With -redact, write the identifier to name mapping to this `file`")
+	flag.StringVar(&opts.owners, "owners", "", "CODEOWNERS `file`, or a file of `import/path/prefix team` lines, for the owners statistic (default: the repository's CODEOWNERS)")
+	flag.Var(&opts.baseline, "baseline", "Baseline run `files` (comma-separated or repeated) to compare against, for the diff statistic and -fail-on-regression: go test -json output, or CSV of test,duration or package,test,duration")
+	flag.Var(&opts.failOnRegression, "fail-on-regression", "Exit non-zero if any package or test is slower than the baseline by more than this `percentage`, e.g. 20%")
+	flag.BoolVar(&opts.failOnTestFailure, "fail-on-test-failure", false, "Exit with code 1 if any package or test failed in its latest run")
+	flag.DurationVar(&opts.regressionFloor, "regression-floor", 100*time.Millisecond, "Ignore slowdowns against the baseline smaller than this duration")
+	flag.DurationVar(&opts.timeout, "timeout", 0, "The go test -timeout of the run, for the near-timeout statistic (default: detected from timed out packages, else 10m)")
+	opts.nearTimeout = 0.8
+	flag.Var(&opts.nearTimeout, "near-timeout", "Report packages and tests taking more than this `percentage` of the timeout in the near-timeout statistic")
+	flag.BoolVar(&opts.strict, "strict", false, "Fail on input lines that are not JSON test events, and on files of unknown format, instead of skipping them")
+	flag.BoolVar(&opts.cache, "cache", false, "Cache parsed input files, keyed by their content, so that analyzing them again is fast")
+	flag.StringVar(&opts.cacheDir, "cache-dir", defaultCacheDir(), "Directory for -cache")
+	flag.BoolVar(&opts.includeCached, "include-cached", false, "Include packages whose results go test reused from its cache, and the tests they replayed")
+	flag.BoolVar(&opts.includeNoTests, "include-no-tests", false, "Include packages without test files in pkg-time output, marked "+testjson.StatusNoTests)
+	flag.BoolVar(&opts.normalizeTime, "normalize-time", false, "Shift each file's timestamps to start at the same instant, for runs from machines with skewed clocks")
+	flag.BoolVar(&opts.deterministic, "deterministic", false, "Make identical inputs produce byte-identical output, as for golden files: implies -normalize-time, and -color never unless set")
+	flag.Float64Var(&opts.outlierK, "outlier-k", 3, "Flag tests as outliers when their latest duration is more than `k` deviations from their earlier ones")
+	flag.StringVar(&opts.outlierMethod, "outlier-method", "stddev", "Deviation `method` used by the outliers statistic: stddev|mad")
+	flag.BoolVar(&opts.detectGit, "git", false, "Tag runs with the commit, branch and commit time checked out in the working directory")
+	flag.Var(&opts.git.commits, "commit", "Tag runs with a commit: `sha` for all files, or file=sha, repeatable")
+	flag.Var(&opts.git.branches, "branch", "Tag runs with a branch: `name` for all files, or file=name, repeatable")
+	flag.Var(&opts.git.times, "commit-time", "Tag runs with a commit time: `time` (RFC 3339 or YYYY-MM-DD) for all files, or file=time, repeatable")
+	flag.Var(&opts.since, "since", "Only include commits from this `date` on in the trend statistic")
+	flag.Var(&opts.until, "until", "Only include commits up to this `date` in the trend statistic")
+	flag.StringVar(&opts.heatmapBy, "heatmap-by", "duration", "What heatmap cells show: "+strings.Join(heatmapModes, "|"))
+	flag.Var(&opts.dims.labels, "label", "Label runs along a dimension to compare them by: `key=value` for all files, or file:key=value, repeatable")
+	flag.Var(&opts.dims.patterns, "label-pattern", "Label runs with the named groups of a `regexp` matched against their path, such as `(?P<os>linux|windows)`, repeatable")
+	flag.StringVar(&opts.compareBy, "compare-by", "", "Label `key` the compare-by statistic contrasts test durations and failures across")
+	flag.Var(&opts.runLabels, "run-label", "Label runs are reported under instead of their file name: `label` for all files, or file=label, repeatable")
+	addLogFlags(flag.CommandLine)
+	oldUsage := flag.Usage
+	flag.Usage = func() {
+		oldUsage()
+		fmt.Printf("\nArguments: [file1.json file2.json ... fileN.json], directories (searched recursively) or glob patterns such as `artifacts/**/*.json`;\nor a single file or `-` (stdin) with -follow\n\n")
+		fmt.Printf("Parses files generated by `go test -json f.json` and computes test set statistics.\n")
+		fmt.Printf("\nSubcommands (see `goteststats <subcommand> -h`): %s\n", strings.Join(subcommandNames(), ", "))
+	}
+	flag.Parse()
+	start := time.Now()
+
+	args := flag.Args()
+
+	if configPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			configPath = findConfig(wd)
+		}
+	}
+	if configPath != "" {
+		c, err := readConfig(configPath)
+		if err != nil {
+			fatal(err)
+		}
+		set := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		c.apply(&opts, set)
+	}
+
+	var sortErr error
+	opts.sort, sortErr = parseSort(sortSpec)
+	gitErr := opts.git.check()
+
+	switch {
+	case opts.statistic == "" && opts.format == "text" && !opts.hasThresholds() && !opts.summary && !opts.failOnTestFailure:
+		fmt.Printf("The `-statistic` flag is required unless `-summary`, `-fail-on-test-failure`, a `-fail-if-*` threshold or another `-format` is set.\n\n")
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.statistic != "" && !isStatistic(opts.statistic) && !isPlugin(opts.statistic):
+		fmt.Printf("The `-statistic` flag must be one of `%s`, or name a plugin `%s<statistic>` on PATH.\n\n", strings.Join(statisticNames(), "`, `"), pluginPrefix)
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.statistic == "coverage" && opts.coverProfile == "":
+		fmt.Printf("The `coverage` statistic requires the `-coverprofile` flag.\n\n")
+		flag.Usage()
+		os.Exit(exitUsage)
+	case sortErr != nil:
+		fmt.Printf("The `-sort` flag is invalid: %v.\n\n", sortErr)
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.groupBy != "" && opts.groupBy != "pkg":
+		fmt.Printf("The `-group-by` flag must be one of `%s`.\n\n", strings.Join(groupings, "`, `"))
+		flag.Usage()
+		os.Exit(exitUsage)
+	case !isDurationFormat(opts.durationFormat):
+		fmt.Printf("The `-duration-format` flag must be one of `%s`.\n\n", strings.Join(durationFormats, "`, `"))
+		flag.Usage()
+		os.Exit(exitUsage)
+	case (opts.statistic == "diff" || opts.failOnRegression > 0) && len(opts.baseline) == 0:
+		fmt.Printf("The `diff` statistic and `-fail-on-regression` require the `-baseline` flag.\n\n")
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.redactMap != "" && !opts.redact:
+		fmt.Printf("The `-redact-map` flag requires `-redact`.\n\n")
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.redact && redactRefused[opts.statistic]:
+		fmt.Printf("The `%s` statistic prints test output, which `-redact` cannot clean.\n\n", opts.statistic)
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.outlierMethod != "stddev" && opts.outlierMethod != "mad":
+		fmt.Printf("The `-outlier-method` flag must be one of `%s`.\n\n", strings.Join(outlierMethods, "`, `"))
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.statistic == "compare-by" && opts.compareBy == "":
+		fmt.Printf("The `compare-by` statistic requires the `-compare-by` flag.\n\n")
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.heatmapBy != "duration" && opts.heatmapBy != "status":
+		fmt.Printf("The `-heatmap-by` flag must be one of `%s`.\n\n", strings.Join(heatmapModes, "`, `"))
+		flag.Usage()
+		os.Exit(exitUsage)
+	case gitErr != nil:
+		fmt.Printf("The `-commit-time` flag is invalid: %v.\n\n", gitErr)
+		flag.Usage()
+		os.Exit(exitUsage)
+	case logger.verbose && logger.quiet:
+		fmt.Printf("The `-v` and `-q` flags cannot be combined.\n\n")
+		flag.Usage()
+		os.Exit(exitUsage)
+	case !isColorMode(opts.colorMode):
+		fmt.Printf("The `-color` flag must be one of `%s`.\n\n", strings.Join(colorModes, "`, `"))
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.follow && len(args) > 0 && args[0] != "-" && (opts.hasThresholds() || opts.failOnTestFailure || opts.redactMap != "" || opts.format == "compact" && opts.summary):
+		fmt.Printf("Following a file never ends, so `-follow` with a file cannot be combined with `-fail-*` gates, `-redact-map` or the `compact` summary; follow stdin (`-`) instead.\n\n")
+		flag.Usage()
+		os.Exit(exitUsage)
+	case opts.format == "template" && opts.templateFile == "":
+		fmt.Printf("The `template` format requires the `-template-file` flag.\n\n")
+		flag.Usage()
+		os.Exit(exitUsage)
+	case !isFormat(opts.format):
+		fmt.Printf("The `-format` flag must be one of `%s`.\n\n", strings.Join(formats, "`, `"))
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+	if opts.deterministic {
+		// Besides the inputs, only the time they were recorded at and
+		// whether stdout is a terminal change the output.
+		opts.normalizeTime = true
+		if opts.colorMode == "auto" {
+			opts.colorMode = "never"
+		}
+	}
+	opts.color = useColor(opts.colorMode)
+
+	f, err := newFilter(opts)
+	if err != nil {
+		fatal(err)
+	}
+	if len(opts.baseline) > 0 {
+		opts.base = newStats()
+		opts.base.filter = f
+		opts.base.read = opts.readOptions()
+		opts.base.IncludeCached = opts.includeCached
+		opts.base.addBaseline(opts.baseline)
+	}
+	if opts.detectGit {
+		if opts.git.detected, err = detectGit(); err != nil {
+			fatal(err)
+		}
+	}
+	stats := newStats()
+	stats.filter = f
+	stats.read = opts.readOptions()
+	stats.labels = opts.runLabels
+	stats.IncludeCached = opts.includeCached
+	// TAP diagnostics, TeamCity messages and Buildkite annotations include
+	// the output of tests.
+	stats.CaptureOutput = opts.format == "tap" || opts.format == "teamcity" || opts.format == "buildkite"
+	if opts.git.enabled() {
+		stats.git = &opts.git
+	}
+	if opts.dims.enabled() {
+		stats.dims = &opts.dims
+	}
+	if opts.redact {
+		r, err := newRedactor()
+		if err != nil {
+			fatal(err)
+		}
+		stats.redactor = r
+	}
+	if opts.follow {
+		path := "-"
+		if len(args) > 0 {
+			path = args[0]
+		}
+		stats.Tags = stats.tagsOf(path)
+		redraw := func() {
+			fmt.Print(clearScreen)
+			if err := render(os.Stdout, stats, opts); err != nil {
+				fatal(err)
+			}
+		}
+		if opts.format == "compact" {
+			// Printed as packages complete rather than redrawn.
+			stats.OnResult = newCompactWriter(os.Stdout, opts).add
+			redraw = func() {}
+		}
+		err = follow(path, stats, opts.interval, redraw)
+		if err != nil {
+			fatal(err)
+		}
+		if opts.format == "compact" && opts.summary {
+			printSummary(os.Stdout, stats, opts)
+		}
+	} else {
+		stats.addFiles(args)
+		if err := render(os.Stdout, stats, opts); err != nil {
+			fatal(err)
+		}
+	}
+	if opts.redactMap != "" {
+		if err := stats.redactor.writeMapping(opts.redactMap); err != nil {
+			fatal(err)
+		}
+	}
+	logInfo("analysis done", "elapsed", time.Since(start))
+	var failures []string
+	if opts.failOnTestFailure {
+		failures = stats.testFailures()
+	}
+	if len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, "Test failures:\n")
+		for _, f := range failures {
+			fmt.Fprintf(os.Stderr, "%s\n", f)
+		}
+	}
+	if violations := stats.thresholdViolations(opts); len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "Duration thresholds exceeded:\n")
+		for _, v := range violations {
+			fmt.Fprintf(os.Stderr, "%s\n", v)
+		}
+		os.Exit(exitThresholdExceeded)
+	}
+	if len(failures) > 0 {
+		os.Exit(exitTestFailure)
+	}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// passCount counts the passed tests it is given.
+type passCount struct {
+	passes int
+}
+
+func (c *passCount) Name() string { return "pass-count" }
+
+func (c *passCount) Accumulate(event testjson.RawLine) {
+	if event.Test != "" && event.Action == "pass" {
+		c.passes++
+	}
+}
+
+func (c *passCount) Report(w io.Writer, format string) error {
+	_, err := fmt.Fprintf(w, "pass-count\t%d\n", c.passes)
+	return err
+}
+
+func init() {
+	testjson.RegisterStatistic(func() testjson.Statistic { return &passCount{} })
+}
+
+// TestMain runs Main instead of the tests when re-executed by runMain.
+func TestMain(m *testing.M) {
+	if os.Getenv("GOTESTSTATS_TEST_MAIN") != "" {
+		os.Args = append([]string{"goteststats"}, os.Args[1:]...)
+		Main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs goteststats with args in a process of its own, since Main
+// parses the command line flags and exits.
+func runMain(t *testing.T, args ...string) string {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "GOTESTSTATS_TEST_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("goteststats %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestRegisteredStatistic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	events := `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"p","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"p","Test":"TestA","Elapsed":1}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"p","Test":"TestB"}
+{"Time":"2024-01-01T00:00:02Z","Action":"fail","Package":"p","Test":"TestB","Elapsed":1}
+{"Time":"2024-01-01T00:00:02Z","Action":"fail","Package":"p","Elapsed":2}
+`
+	if err := os.WriteFile(path, []byte(events), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, want := runMain(t, "-q", "-statistic", "pass-count", path), "pass-count\t1\n"; out != want {
+		t.Errorf("output %q, want %q", out, want)
+	}
+}
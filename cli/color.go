@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// colorModes lists the values accepted by the `-color` flag.
+var colorModes = []string{"auto", "always", "never"}
+
+const (
+	colorRed    = "31"
+	colorGreen  = "32"
+	colorYellow = "33"
+)
+
+// useColor resolves a `-color` mode: auto colors only when stdout is a
+// terminal and NO_COLOR (https://no-color.org) is unset.
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+	}
+}
+
+// paint wraps text in an ANSI color escape when color output is enabled.
+func (o options) paint(color, text string) string {
+	if !o.color || color == "" {
+		return text
+	}
+	return "\x1b[" + color + "m" + text + "\x1b[0m"
+}
+
+// statusColor is red for failures, yellow for passes slower than
+// -slow-threshold and green for other passes.
+func (o options) statusColor(passed bool, duration time.Duration) string {
+	switch {
+	case !passed:
+		return colorRed
+	case o.slowThreshold > 0 && duration > o.slowThreshold:
+		return colorYellow
+	default:
+		return colorGreen
+	}
+}
+
+func isColorMode(mode string) bool {
+	for _, m := range colorModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// compactWriter prints one line per package as its result arrives, with the
+// number of tests and failures seen for it, in the spirit of go test's own
+// `ok`/`FAIL` lines.
+type compactWriter struct {
+	w      io.Writer
+	opts   options
+	tests  map[pkgid]int
+	failed map[pkgid]int
+}
+
+func newCompactWriter(w io.Writer, opts options) *compactWriter {
+	return &compactWriter{w: w, opts: opts, tests: make(map[pkgid]int), failed: make(map[pkgid]int)}
+}
+
+func (c *compactWriter) add(r *testjson.Result) {
+	if r.Kind == "test" {
+		if parentTest(r.Test) == "" {
+			c.tests[r.Package]++
+			if r.Status == "fail" {
+				c.failed[r.Package]++
+			}
+		}
+		return
+	}
+	d := time.Duration(r.Duration * float64(time.Second))
+	line := fmt.Sprintf("%s\t%s\t%s\t%d tests\t%d failed", r.Status, r.Package, c.opts.dur(d), c.tests[r.Package], c.failed[r.Package])
+	color := c.opts.statusColor(r.Status != "fail", 0)
+	if r.Status == testjson.StatusNoTests {
+		color = ""
+	}
+	fmt.Fprintln(c.w, c.opts.paint(color, line))
+	delete(c.tests, r.Package)
+	delete(c.failed, r.Package)
+}
+
+// writeCompact prints the package lines of all results ingested so far.
+func writeCompact(w io.Writer, s *stats, opts options) error {
+	c := newCompactWriter(w, opts)
+	for _, r := range s.Results {
+		c.add(r)
+	}
+	if opts.summary {
+		printSummary(w, s, opts)
+	}
+	return nil
+}
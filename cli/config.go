@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+	"gopkg.in/yaml.v3"
+)
+
+// configNames are the file names searched for, from the working directory up
+// to the repository root, when `-config` is not given.
+var configNames = []string{".goteststats.yaml", ".goteststats.yml"}
+
+// config is the test-health policy a repository can check in alongside its
+// code. Every setting is a default that the corresponding flag overrides.
+type config struct {
+	Statistic string `yaml:"statistic"`
+	Format    string `yaml:"format"`
+	Filters   struct {
+		Packages string `yaml:"packages"`
+		Tests    string `yaml:"tests"`
+		Kinds    string `yaml:"kinds"`
+	} `yaml:"filters"`
+	Thresholds struct {
+		Test    time.Duration `yaml:"test"`
+		Package time.Duration `yaml:"package"`
+	} `yaml:"thresholds"`
+	Budgets map[pkgid]time.Duration `yaml:"budgets"`
+	Ignore  []string                `yaml:"ignore"`
+}
+
+// findConfig walks up from dir looking for a config file, stopping at the
+// directory containing `.git`. It returns an empty path if there is none.
+func findConfig(dir string) string {
+	for {
+		for _, name := range configNames {
+			p := filepath.Join(dir, name)
+			if _, err := os.Stat(p); err == nil {
+				return p
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func readConfig(p string) (*config, error) {
+	bytes, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var c config
+	if err := yaml.Unmarshal(bytes, &c); err != nil {
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	return &c, nil
+}
+
+// apply copies config settings into opts for every flag not set explicitly.
+func (c *config) apply(opts *options, set map[string]bool) {
+	if !set["statistic"] && c.Statistic != "" {
+		opts.statistic = c.Statistic
+	}
+	if !set["format"] && c.Format != "" {
+		opts.format = c.Format
+	}
+	if !set["pkg-filter"] && c.Filters.Packages != "" {
+		opts.pkgFilter = c.Filters.Packages
+	}
+	if !set["test-filter"] && c.Filters.Tests != "" {
+		opts.testFilter = c.Filters.Tests
+	}
+	if !set["kind"] && c.Filters.Kinds != "" {
+		opts.kind = c.Filters.Kinds
+	}
+	if !set["fail-if-test-over"] && c.Thresholds.Test > 0 {
+		opts.testOver = c.Thresholds.Test
+	}
+	if !set["fail-if-pkg-over"] && c.Thresholds.Package > 0 {
+		opts.pkgOver = c.Thresholds.Package
+	}
+	opts.budgets = c.Budgets
+	opts.ignore = append(opts.ignore, c.Ignore...)
+}
+
+// filter decides which events contribute to the statistics.
+type filter struct {
+	pkgs   *regexp.Regexp
+	tests  *regexp.Regexp
+	kinds  map[string]bool
+	ignore []string
+}
+
+func newFilter(opts options) (*filter, error) {
+	f := &filter{ignore: opts.ignore}
+	var err error
+	if opts.pkgFilter != "" {
+		if f.pkgs, err = regexp.Compile(opts.pkgFilter); err != nil {
+			return nil, fmt.Errorf("invalid package filter: %v", err)
+		}
+	}
+	if opts.testFilter != "" {
+		if f.tests, err = regexp.Compile(opts.testFilter); err != nil {
+			return nil, fmt.Errorf("invalid test filter: %v", err)
+		}
+	}
+	if f.kinds, err = parseKinds(opts.kind); err != nil {
+		return nil, fmt.Errorf("invalid kind filter: %v", err)
+	}
+	return f, nil
+}
+
+func (f *filter) excludes(line testjson.RawLine) bool {
+	if f == nil {
+		return false
+	}
+	if f.pkgs != nil && !f.pkgs.MatchString(line.Package) {
+		return true
+	}
+	if line.Test == "" {
+		return false
+	}
+	if f.tests != nil && !f.tests.MatchString(line.Test) {
+		return true
+	}
+	if f.kinds != nil && !f.kinds[testKind(line.Test)] {
+		return true
+	}
+	return f.ignored(line.Package, line.Test)
+}
+
+// ignored reports whether a test, or the test it is a subtest of, is listed
+// either by name or as `pkg#name`.
+func (f *filter) ignored(pkg pkgid, name string) bool {
+	for _, ig := range f.ignore {
+		n := name
+		if strings.Contains(ig, "#") {
+			n = testjson.TestID(pkg, name)
+		}
+		if n == ig || strings.HasPrefix(n, ig+"/") {
+			return true
+		}
+	}
+	return false
+}
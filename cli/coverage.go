@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lowCoverage is the statement coverage ratio below which a package that
+// takes at least the average package time is flagged as a poor return on
+// the time spent testing it.
+const lowCoverage = 0.5
+
+type pkgCoverage struct {
+	id         pkgid
+	duration   time.Duration
+	statements int
+	covered    int
+	lowROI     bool
+}
+
+func (c *pkgCoverage) ratio() float64 {
+	if c.statements == 0 {
+		return 0
+	}
+	return float64(c.covered) / float64(c.statements)
+}
+
+type coverBlock struct {
+	statements int
+	count      int
+}
+
+// readCoverProfile reads a profile written by `go test -coverprofile` and
+// returns the blocks of every source file keyed by their position. Blocks
+// repeated across merged profiles are counted once.
+func readCoverProfile(p string) (map[string]map[string]*coverBlock, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	files := make(map[string]map[string]*coverBlock)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		colon := strings.LastIndex(fields[0], ":")
+		if len(fields) != 3 || colon < 0 {
+			return nil, fmt.Errorf("%s:%d: malformed coverage block %q", p, n, line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", p, n, err)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", p, n, err)
+		}
+		file, pos := fields[0][:colon], fields[0][colon+1:]
+		blocks, ok := files[file]
+		if !ok {
+			blocks = make(map[string]*coverBlock)
+			files[file] = blocks
+		}
+		if b, ok := blocks[pos]; ok {
+			b.count += count
+		} else {
+			blocks[pos] = &coverBlock{statements: statements, count: count}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (s *stats) coverageSortedByDurationDescending(profile map[string]map[string]*coverBlock) []*pkgCoverage {
+	byPkg := make(map[pkgid]*pkgCoverage)
+	get := func(id pkgid) *pkgCoverage {
+		c, ok := byPkg[id]
+		if !ok {
+			c = &pkgCoverage{id: id}
+			byPkg[id] = c
+		}
+		return c
+	}
+	for _, p := range s.Packages {
+		get(p.ID).duration = p.Duration
+	}
+	for file, blocks := range profile {
+		c := get(path.Dir(file))
+		for _, b := range blocks {
+			c.statements += b.statements
+			if b.count > 0 {
+				c.covered += b.statements
+			}
+		}
+	}
+
+	var out []*pkgCoverage
+	var total time.Duration
+	for _, c := range byPkg {
+		out = append(out, c)
+		total += c.duration
+	}
+	if len(out) > 0 {
+		avg := total / time.Duration(len(out))
+		for _, c := range out {
+			c.lowROI = c.statements > 0 && c.duration >= avg && c.ratio() < lowCoverage
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].duration != out[j].duration {
+			return out[j].duration < out[i].duration
+		}
+		return out[i].id < out[j].id
+	})
+	return out
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+type crash struct {
+	pkg     pkgid
+	kind    string
+	message string
+	// running lists the tests that had started but not finished.
+	running []string
+	time    time.Time
+}
+
+// crashKind classifies output lines that mark a test binary dying without
+// clean per-test results, returning "" for ordinary output.
+func crashKind(out string) string {
+	switch {
+	case strings.HasPrefix(out, "panic: test timed out"):
+		return "timeout"
+	case strings.HasPrefix(out, "panic: "), strings.HasPrefix(out, "fatal error: "):
+		return "panic"
+	case strings.HasPrefix(out, "FAIL") && (strings.HasSuffix(out, "[build failed]") || strings.HasSuffix(out, "[setup failed]")):
+		return "build"
+	}
+	return ""
+}
+
+// recordCrash tracks running tests and records the first crash marker seen
+// in each run of a package.
+func (s *stats) recordCrash(line testjson.RawLine) {
+	switch line.Action {
+	case "run":
+		if line.Test != "" {
+			s.running[line.Package] = append(s.running[line.Package], line.Test)
+		}
+	case "pass", "fail", "skip":
+		if line.Test == "" {
+			delete(s.running, line.Package)
+			delete(s.crashed, line.Package)
+			return
+		}
+		running := s.running[line.Package]
+		for i, name := range running {
+			if name == line.Test {
+				s.running[line.Package] = append(running[:i:i], running[i+1:]...)
+				break
+			}
+		}
+	case "output":
+		out := strings.TrimSpace(line.Output)
+		kind := crashKind(out)
+		if kind == "" || s.crashed[line.Package] {
+			return
+		}
+		s.crashed[line.Package] = true
+		s.crashes = append(s.crashes, &crash{
+			pkg:     line.Package,
+			kind:    kind,
+			message: strings.ReplaceAll(out, "\t", " "),
+			running: append([]string(nil), s.running[line.Package]...),
+			time:    line.Time,
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// utilizationBuckets is the number of intervals the run's concurrency is
+// reported over.
+const utilizationBuckets = 10
+
+type concurrency struct {
+	start, end time.Time
+	busy       time.Duration
+	peak       int
+	buckets    []float64
+}
+
+// packageConcurrency measures how many packages ran at once over the run.
+func packageConcurrency(pkgs []*testjson.Package) concurrency {
+	var c concurrency
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	for _, p := range pkgs {
+		if c.start.IsZero() || p.Start.Before(c.start) {
+			c.start = p.Start
+		}
+		if p.End.After(c.end) {
+			c.end = p.End
+		}
+		c.busy += p.End.Sub(p.Start)
+		edges = append(edges, edge{p.Start, 1}, edge{p.End, -1})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if !edges[i].at.Equal(edges[j].at) {
+			return edges[i].at.Before(edges[j].at)
+		}
+		return edges[i].delta < edges[j].delta
+	})
+	running := 0
+	for _, e := range edges {
+		running += e.delta
+		if running > c.peak {
+			c.peak = running
+		}
+	}
+
+	wall := c.end.Sub(c.start)
+	c.buckets = make([]float64, utilizationBuckets)
+	if wall <= 0 {
+		return c
+	}
+	width := wall / utilizationBuckets
+	for _, p := range pkgs {
+		for i := range c.buckets {
+			from := c.start.Add(width * time.Duration(i))
+			to := from.Add(width)
+			overlap := minTime(to, p.End).Sub(maxTime(from, p.Start))
+			if overlap > 0 {
+				c.buckets[i] += float64(overlap) / float64(width)
+			}
+		}
+	}
+	return c
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// criticalPath walks back from the package finishing last, each time to the
+// package that finished latest before the current one started: the one whose
+// slot it most plausibly waited for. The chain is returned in run order.
+func criticalPath(pkgs []*testjson.Package) []*testjson.Package {
+	var last *testjson.Package
+	for _, p := range pkgs {
+		if last == nil || p.End.After(last.End) {
+			last = p
+		}
+	}
+	var chain []*testjson.Package
+	for cur := last; cur != nil; {
+		chain = append(chain, cur)
+		var prev *testjson.Package
+		for _, p := range pkgs {
+			if p != cur && !p.End.After(cur.Start) && (prev == nil || p.End.After(prev.End)) {
+				prev = p
+			}
+		}
+		cur = prev
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+func printCriticalPath(w io.Writer, s *stats, opts options) {
+	slots := opts.slots
+	pkgs := s.PackagesByDuration()
+	c := packageConcurrency(pkgs)
+	if slots <= 0 {
+		slots = c.peak
+	}
+	wall := c.end.Sub(c.start)
+	utilization := 0.0
+	if wall > 0 && slots > 0 {
+		utilization = float64(c.busy) / float64(wall) / float64(slots)
+	}
+	fmt.Fprintf(w, "wall\t%s\n", opts.dur(wall))
+	fmt.Fprintf(w, "busy\t%s\n", opts.dur(c.busy))
+	fmt.Fprintf(w, "peak\t%d\n", c.peak)
+	fmt.Fprintf(w, "utilization\t%.1f%% of %d slots\n", 100*utilization, slots)
+	width := (wall / utilizationBuckets).Round(time.Millisecond)
+	for i, b := range c.buckets {
+		fmt.Fprintf(w, "running\t%s-%s\t%.1f\n", opts.dur(width*time.Duration(i)), opts.dur(width*time.Duration(i+1)), b)
+	}
+	for _, p := range criticalPath(pkgs) {
+		fmt.Fprintf(w, "critical\t%s\t+%s\t%s\n", p.ID, opts.dur(p.Start.Sub(c.start)), opts.dur(p.Duration))
+	}
+}
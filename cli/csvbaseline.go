@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// addBaseline reads baseline runs: `go test -json` output like addFiles, or
+// CSV files of prior durations.
+func (s *stats) addBaseline(args []string) {
+	var events []string
+	for _, arg := range args {
+		if strings.EqualFold(path.Ext(arg), ".csv") {
+			if err := s.addCSV(arg); err != nil {
+				fatal(err)
+			}
+			continue
+		}
+		events = append(events, arg)
+	}
+	if len(events) > 0 {
+		s.addFiles(events)
+	}
+}
+
+// addCSV reads prior durations from CSV with `test,duration` or
+// `package,test,duration` records. A header row naming the columns package,
+// test (or name) and duration (or elapsed) may put them in any order.
+// Durations are Go durations such as `1.5s` or plain seconds. Records without
+// a test give package durations; tests without a package match the test of
+// that name in any package. Tests listed more than once get their mean.
+func (s *stats) addCSV(file string) error {
+	f, err := openInput(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	pkgCol, testCol, durCol := -1, 0, 1
+	sums := make(map[id]time.Duration)
+	counts := make(map[id]int)
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		if n == 1 {
+			if cols, ok := csvHeader(record); ok {
+				pkgCol, testCol, durCol = cols[0], cols[1], cols[2]
+				continue
+			}
+			if len(record) >= 3 {
+				pkgCol, testCol, durCol = 0, 1, 2
+			}
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		d, err := parseCSVDuration(field(durCol))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", file, n, err)
+		}
+		pkgPath, name := field(pkgCol), field(testCol)
+		if pkgPath == "" && name == "" || s.csvExcludes(pkgPath, name) {
+			continue
+		}
+		key := pkgPath
+		if name != "" {
+			key = testjson.TestID(pkgPath, name)
+		}
+		if counts[key] == 0 {
+			if name == "" {
+				s.Packages[key] = &testjson.Package{ID: pkgPath, Passed: true}
+			} else {
+				s.Tests[key] = &testjson.Test{Package: pkgPath, Name: name, Passed: true}
+			}
+		}
+		sums[key] += d
+		counts[key]++
+	}
+	for key, sum := range sums {
+		mean := sum / time.Duration(counts[key])
+		if p, ok := s.Packages[key]; ok {
+			p.Duration = mean
+		}
+		if t, ok := s.Tests[key]; ok {
+			t.Duration = mean
+		}
+	}
+	return nil
+}
+
+// csvHeader returns the package, test and duration columns named by a header
+// row, the package column being -1 when absent.
+func csvHeader(record []string) ([3]int, bool) {
+	cols := [3]int{-1, -1, -1}
+	for i, name := range record {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "package", "pkg":
+			cols[0] = i
+		case "test", "name":
+			cols[1] = i
+		case "duration", "elapsed", "seconds":
+			cols[2] = i
+		}
+	}
+	return cols, cols[1] >= 0 && cols[2] >= 0
+}
+
+func parseCSVDuration(v string) (time.Duration, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return d, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+	return fromSeconds(f), nil
+}
+
+// csvExcludes applies the filters to a CSV record, ignoring the package
+// filter for tests listed without a package.
+func (s *stats) csvExcludes(pkg pkgid, name string) bool {
+	if s.filter == nil {
+		return false
+	}
+	f := *s.filter
+	if pkg == "" {
+		f.pkgs = nil
+	}
+	return f.excludes(testjson.RawLine{Package: pkg, Test: name})
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// fileList is a flag accepting comma-separated file names, repeatable.
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(v string) error {
+	for _, p := range strings.Split(v, ",") {
+		if p != "" {
+			*f = append(*f, p)
+		}
+	}
+	return nil
+}
+
+type delta struct {
+	pkg  pkgid
+	name string
+	base time.Duration
+	head time.Duration
+}
+
+func (d delta) change() time.Duration {
+	return d.head - d.base
+}
+
+// ratio is the relative change from base to head, or 0 for a zero base.
+func (d delta) ratio() float64 {
+	if d.base == 0 {
+		return 0
+	}
+	return float64(d.change()) / float64(d.base)
+}
+
+// testDeltas pairs the tests present in both runs, largest slowdown first.
+// Baseline tests without a package, as from CSV, match by name alone.
+func testDeltas(base, head *stats) []delta {
+	var out []delta
+	for k, t := range head.Tests {
+		b, ok := base.Tests[k]
+		if !ok {
+			b, ok = base.Tests[testjson.TestID("", t.Name)]
+		}
+		if ok {
+			out = append(out, delta{pkg: t.Package, name: t.Name, base: b.Duration, head: t.Duration})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].change() != out[j].change() {
+			return out[j].change() < out[i].change()
+		}
+		return testjson.TestID(out[i].pkg, out[i].name) < testjson.TestID(out[j].pkg, out[j].name)
+	})
+	return out
+}
+
+// testRegressions returns the tests that got slower by more than the
+// -regression-floor and, when it is set, the -fail-on-regression percentage.
+func testRegressions(base, head *stats, opts options) []delta {
+	var out []delta
+	for _, d := range testDeltas(base, head) {
+		if d.change() <= opts.regressionFloor {
+			break
+		}
+		if opts.failOnRegression == 0 || d.ratio() > float64(opts.failOnRegression) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// packageDeltas pairs the packages present in both runs, largest slowdown
+// first.
+func packageDeltas(base, head *stats) []delta {
+	var out []delta
+	for k, p := range head.Packages {
+		if b, ok := base.Packages[k]; ok {
+			out = append(out, delta{pkg: p.ID, base: b.Duration, head: p.Duration})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].change() != out[j].change() {
+			return out[j].change() < out[i].change()
+		}
+		return testjson.TestID(out[i].pkg, out[i].name) < testjson.TestID(out[j].pkg, out[j].name)
+	})
+	return out
+}
+
+// percent is a flag accepting a percentage such as `20%`, stored as a ratio.
+type percent float64
+
+func (p *percent) String() string {
+	return strconv.FormatFloat(float64(*p)*100, 'f', -1, 64) + "%"
+}
+
+func (p *percent) Set(v string) error {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "%"), 64)
+	if err != nil || f < 0 {
+		return fmt.Errorf("invalid percentage %q", v)
+	}
+	*p = percent(f / 100)
+	return nil
+}
+
+// regressed reports whether d got slower than -fail-on-regression allows:
+// by more than the percentage and by more than the absolute floor.
+func (o options) regressed(d delta) bool {
+	return o.failOnRegression > 0 && d.change() > o.regressionFloor && d.ratio() > float64(o.failOnRegression)
+}
+
+// regressionViolations lists the packages and tests of s that regressed
+// against the baseline run.
+func (s *stats) regressionViolations(opts options) []string {
+	if opts.base == nil || opts.failOnRegression <= 0 {
+		return nil
+	}
+	var out []string
+	for _, d := range packageDeltas(opts.base, s) {
+		if opts.regressed(d) {
+			out = append(out, fmt.Sprintf("regression\tpkg\t%s\t%s -> %s\t%+.0f%% > %v", d.pkg, opts.dur(d.base), opts.dur(d.head), 100*d.ratio(), &opts.failOnRegression))
+		}
+	}
+	for _, d := range testDeltas(opts.base, s) {
+		if opts.regressed(d) {
+			out = append(out, fmt.Sprintf("regression\ttest\t%s\t%s\t%s -> %s\t%+.0f%% > %v", d.name, d.pkg, opts.dur(d.base), opts.dur(d.head), 100*d.ratio(), &opts.failOnRegression))
+		}
+	}
+	return out
+}
+
+// printDiff compares s with the baseline run, packages then tests, each
+// largest slowdown first.
+func printDiff(w io.Writer, s *stats, opts options) {
+	for _, d := range packageDeltas(opts.base, s) {
+		fmt.Fprintf(w, "pkg\t%s\t%s\t%s\t%s\t%+.1f%%\n", d.pkg, opts.dur(d.base), opts.dur(d.head), signed(opts.dur(d.change())), 100*d.ratio())
+	}
+	for _, d := range testDeltas(opts.base, s) {
+		line := fmt.Sprintf("test\t%s\t%s\t%s\t%s\t%s\t%+.1f%%", d.name, d.pkg, opts.dur(d.base), opts.dur(d.head), signed(opts.dur(d.change())), 100*d.ratio())
+		if opts.regressed(d) {
+			line = opts.paint(colorRed, line)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// signed prefixes non-negative formatted durations with a plus sign.
+func signed(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return s
+	}
+	return "+" + s
+}
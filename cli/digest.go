@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+const defaultDigestTemplate = `<!DOCTYPE html>
+<html>
+<body style="font: 14px sans-serif; color: #212121;">
+<h2 style="margin: 0 0 4px;">{{.Title}}</h2>
+<p style="margin: 0 0 16px; color: #616161;">The latest {{.Window}} runs, in bold, against those before: {{len .NewFailures}} new failures, {{len .NewFlakes}} new flakes, {{len .Regressions}} regressions.</p>
+
+<h3>Total time</h3>
+<table cellpadding="4" cellspacing="0" style="border-collapse: collapse;">
+<tr style="text-align: left;"><th>run</th><th>time</th><th>total</th><th>change</th><th>tests</th><th>failures</th><th></th></tr>
+{{- range .Runs}}
+<tr{{if .Summarized}} style="font-weight: bold;"{{end}}><td>{{.Name}}</td><td>{{.Time.Format "2006-01-02 15:04"}}</td><td>{{dur .Suite}}</td><td>{{if .Change}}{{signed .Change}}{{end}}</td><td>{{.Tests}}</td><td{{if .Failures}} style="color: #b71c1c;"{{end}}>{{.Failures}}</td><td><div style="background: #90caf9; height: 10px; width: {{.Width}}px;"></div></td></tr>
+{{- end}}
+</table>
+{{- if .NewFailures}}
+
+<h3>New failures</h3>
+<table cellpadding="4" cellspacing="0" style="border-collapse: collapse;">
+<tr style="text-align: left;"><th>test</th><th>package</th><th>failed</th></tr>
+{{- range .NewFailures}}
+<tr><td><code>{{.Name}}</code></td><td>{{.Package}}</td><td>{{.Failures}}/{{.Runs}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .NewFlakes}}
+
+<h3>New flakes</h3>
+<table cellpadding="4" cellspacing="0" style="border-collapse: collapse;">
+<tr style="text-align: left;"><th>test</th><th>package</th><th>failed</th></tr>
+{{- range .NewFlakes}}
+<tr><td><code>{{.Name}}</code></td><td>{{.Package}}</td><td>{{.Failures}}/{{.Runs}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Regressions}}
+
+<h3>Top regressions</h3>
+<table cellpadding="4" cellspacing="0" style="border-collapse: collapse;">
+<tr style="text-align: left;"><th>test</th><th>package</th><th>before</th><th>now</th><th>change</th></tr>
+{{- range .Regressions}}
+<tr><td><code>{{.Name}}</code></td><td>{{.Package}}</td><td>{{dur .Base}}</td><td>{{dur .Head}}</td><td>{{signed .Change}} ({{printf "%+.0f%%" .Percent}})</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`
+
+// digestRun is a row of the total time trend: a commit or, for runs without
+// a commit, a run.
+type digestRun struct {
+	Name string
+	Time time.Time
+	// Suite is the total duration of the packages tested, and Change how
+	// much it grew since the previous run.
+	Suite    time.Duration
+	Change   time.Duration
+	Tests    int
+	Failures int
+	// Width is the length of the bar drawn for Suite, in pixels.
+	Width int
+	// Summarized is set for the runs the digest is about.
+	Summarized bool
+}
+
+type digestTest struct {
+	Package  string
+	Name     string
+	Runs     int
+	Failures int
+}
+
+type digestRegression struct {
+	Package string
+	Name    string
+	Base    time.Duration
+	Head    time.Duration
+	Change  time.Duration
+	Percent float64
+}
+
+// digestReport is what the `digest` subcommand renders, and what -template
+// files are executed with.
+type digestReport struct {
+	Title       string
+	Window      int
+	Runs        []digestRun
+	NewFailures []digestTest
+	NewFlakes   []digestTest
+	Regressions []digestRegression
+}
+
+// testOutcomes tallies the results of a test over a set of runs.
+type testOutcomes struct {
+	pkg      pkgid
+	name     string
+	runs     int
+	failures int
+	// passTime totals the duration of the passing runs.
+	passTime time.Duration
+}
+
+func (o *testOutcomes) passes() int {
+	return o.runs - o.failures
+}
+
+func (o *testOutcomes) flaky() bool {
+	return o.failures > 0 && o.passes() > 0
+}
+
+func (o *testOutcomes) meanPassTime() time.Duration {
+	return o.passTime / time.Duration(o.passes())
+}
+
+// tallyResult adds a test result to the outcomes of its test.
+func tallyResult(outcomes map[id]*testOutcomes, r *testjson.Result) {
+	key := testjson.TestID(r.Package, r.Test)
+	t, ok := outcomes[key]
+	if !ok {
+		t = &testOutcomes{pkg: r.Package, name: r.Test}
+		outcomes[key] = t
+	}
+	t.runs++
+	if r.Status == "fail" {
+		t.failures++
+	} else {
+		t.passTime += fromSeconds(r.Duration)
+	}
+}
+
+// digestOptions are the flags of the `digest` subcommand shaping its content.
+type digestOptions struct {
+	window    int
+	trend     int
+	top       int
+	minChange float64
+	floor     time.Duration
+}
+
+// newDigest summarizes the latest window runs against the runs before them.
+// A new failure failed without passing in the window and never failed
+// before; a new flake both passed and failed in the window but was not flaky
+// before. Regressions compare the mean passing duration of a test in the
+// window to that before it.
+func newDigest(s *stats, o digestOptions) digestReport {
+	points := s.trend(time.Time{}, time.Time{})
+	window := o.window
+	if window > len(points) {
+		window = len(points)
+	}
+	summarized := make(map[string]bool)
+	for _, p := range points[len(points)-window:] {
+		summarized[p.key] = true
+	}
+
+	before := make(map[id]*testOutcomes)
+	within := make(map[id]*testOutcomes)
+	for _, r := range s.Results {
+		if r.Kind != "test" || r.Status == "skip" {
+			continue
+		}
+		if summarized[trendKey(r)] {
+			tallyResult(within, r)
+		} else {
+			tallyResult(before, r)
+		}
+	}
+	var keys []id
+	for key := range within {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	d := digestReport{Window: window}
+	for _, key := range keys {
+		t, b := within[key], before[key]
+		dt := digestTest{Package: t.pkg, Name: t.name, Runs: t.runs, Failures: t.failures}
+		switch {
+		case t.failures > 0 && t.passes() == 0 && (b == nil || b.failures == 0):
+			d.NewFailures = append(d.NewFailures, dt)
+		case t.flaky() && (b == nil || !b.flaky()):
+			d.NewFlakes = append(d.NewFlakes, dt)
+		}
+		if b == nil || b.passes() == 0 || t.passes() == 0 {
+			continue
+		}
+		dl := delta{pkg: t.pkg, name: t.name, base: b.meanPassTime(), head: t.meanPassTime()}
+		if dl.change() > o.floor && dl.ratio() > o.minChange {
+			d.Regressions = append(d.Regressions, digestRegression{Package: dl.pkg, Name: dl.name, Base: dl.base, Head: dl.head, Change: dl.change(), Percent: 100 * dl.ratio()})
+		}
+	}
+	sort.SliceStable(d.Regressions, func(i, j int) bool { return d.Regressions[j].Change < d.Regressions[i].Change })
+	if len(d.Regressions) > o.top {
+		d.Regressions = d.Regressions[:o.top]
+	}
+
+	shown := o.trend
+	if shown < window {
+		shown = window
+	}
+	if shown > len(points) {
+		shown = len(points)
+	}
+	var slowest time.Duration
+	for _, p := range points[len(points)-shown:] {
+		if p.suite > slowest {
+			slowest = p.suite
+		}
+	}
+	for i := len(points) - shown; i < len(points); i++ {
+		p := points[i]
+		name := shortCommit(p.commit)
+		if name == "" {
+			name = p.run
+		}
+		run := digestRun{Name: name, Time: p.time, Suite: p.suite, Tests: p.tests, Failures: p.failures, Summarized: summarized[p.key]}
+		if i > 0 {
+			run.Change = p.suite - points[i-1].suite
+		}
+		if slowest > 0 {
+			run.Width = int(200 * p.suite / slowest)
+		}
+		d.Runs = append(d.Runs, run)
+	}
+	if len(d.Runs) > 0 {
+		d.Title = "Test health: " + d.Runs[len(d.Runs)-1].Name
+	}
+	return d
+}
+
+// sendMail sends an HTML message through the SMTP server at addr, with PLAIN
+// authentication when user is set.
+func sendMail(addr, user, password, from string, to []string, subject, body string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, password, host)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(addr, auth, from, to, msg.Bytes())
+}
+
+func digest(args []string) {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	var o digestOptions
+	fs.IntVar(&o.window, "runs", 1, "Number of latest `runs` to summarize against the earlier ones")
+	fs.IntVar(&o.trend, "trend", 10, "Number of latest `runs` to show in the total time trend")
+	fs.IntVar(&o.top, "top", 10, "Number of regressions to include")
+	minChange := percent(0.2)
+	fs.Var(&minChange, "min-change", "Only report tests slower than before by more than this `percentage`")
+	fs.DurationVar(&o.floor, "regression-floor", 100*time.Millisecond, "Ignore slowdowns smaller than this duration")
+	templateFile := fs.String("template", "", "Go html/template `file` for the email body, executed with the digest")
+	smtpAddr := fs.String("smtp", os.Getenv("GOTESTSTATS_SMTP_ADDR"), "SMTP server `host:port` to send the digest through instead of printing it (default $GOTESTSTATS_SMTP_ADDR)")
+	smtpUser := fs.String("smtp-user", os.Getenv("GOTESTSTATS_SMTP_USER"), "SMTP `user`, authenticating with the password in $GOTESTSTATS_SMTP_PASSWORD (default $GOTESTSTATS_SMTP_USER)")
+	from := fs.String("from", "", "Sender `address`")
+	var to fileList
+	fs.Var(&to, "to", "Recipient `addresses` (comma-separated or repeated)")
+	subject := fs.String("subject", "", "Email `subject` (default: a summary of the digest)")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats digest [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Renders an HTML email summarizing the latest runs: the total time trend, new\nfailures, new flakes and top regressions.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	o.minChange = float64(minChange)
+
+	switch {
+	case o.window < 1:
+		fatal("digest: -runs must be at least 1")
+	case o.top < 0:
+		fatal("digest: -top must not be negative")
+	case *smtpAddr != "" && (*from == "" || len(to) == 0):
+		fatal("digest: -smtp requires -from and -to")
+	}
+	text := defaultDigestTemplate
+	if *templateFile != "" {
+		b, err := os.ReadFile(*templateFile)
+		if err != nil {
+			fatal(err)
+		}
+		text = string(b)
+	}
+	human := options{durationFormat: "human"}
+	tmpl, err := template.New("digest").Funcs(template.FuncMap{
+		"dur":    human.dur,
+		"signed": func(d time.Duration) string { return signed(human.dur(d)) },
+	}).Parse(text)
+	if err != nil {
+		fatal(err)
+	}
+
+	s := newStatsFromFiles(fs.Args(), nil)
+	d := newDigest(s, o)
+	if len(d.Runs) == 0 {
+		fatal("digest: no test results")
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, d); err != nil {
+		fatal(err)
+	}
+	if *smtpAddr == "" {
+		fmt.Print(body.String())
+		return
+	}
+	if *subject == "" {
+		*subject = fmt.Sprintf("%s: %d new failures, %d new flakes, %d regressions", d.Title, len(d.NewFailures), len(d.NewFlakes), len(d.Regressions))
+	}
+	if err := sendMail(*smtpAddr, *smtpUser, os.Getenv("GOTESTSTATS_SMTP_PASSWORD"), *from, to, *subject, body.String()); err != nil {
+		fatal(err)
+	}
+	logInfo("sent digest", "to", strings.Join(to, ", "))
+}
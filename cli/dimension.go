@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// dimensionLabels is a repeatable flag attaching labels such as `os=linux`
+// to runs: `key=value` for every file, or `file:key=value` for a file given
+// by path or base name pattern.
+type dimensionLabels []string
+
+func (l *dimensionLabels) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *dimensionLabels) Set(v string) error {
+	i := strings.Index(v, "=")
+	j := strings.LastIndex(v[:i+1], ":")
+	if i <= 0 || j == 0 || j == i-1 {
+		return fmt.Errorf("%q is not of the form [file:]key=value", v)
+	}
+	*l = append(*l, v)
+	return nil
+}
+
+// labelPatterns is a repeatable flag of regular expressions whose named
+// groups, matched against the path of each file, become its labels.
+type labelPatterns []*regexp.Regexp
+
+func (p *labelPatterns) String() string {
+	var out []string
+	for _, re := range *p {
+		out = append(out, re.String())
+	}
+	return strings.Join(out, ",")
+}
+
+func (p *labelPatterns) Set(v string) error {
+	re, err := regexp.Compile(v)
+	if err != nil {
+		return err
+	}
+	named := false
+	for _, name := range re.SubexpNames() {
+		named = named || name != ""
+	}
+	if !named {
+		return fmt.Errorf("%q has no named groups such as (?P<os>[a-z]+)", v)
+	}
+	*p = append(*p, re)
+	return nil
+}
+
+// dimensions label runs along dimensions such as OS, architecture or Go
+// version, to compare results across them.
+type dimensions struct {
+	labels   dimensionLabels
+	patterns labelPatterns
+}
+
+func (d *dimensions) enabled() bool {
+	return len(d.labels) > 0 || len(d.patterns) > 0
+}
+
+// of returns the labels of the run read from path: those extracted by the
+// patterns, overridden by the labels for every file, overridden in turn by
+// the labels given for the file.
+func (d *dimensions) of(path string) map[string]string {
+	out := make(map[string]string)
+	for _, re := range d.patterns {
+		m := re.FindStringSubmatch(filepath.ToSlash(path))
+		for i, name := range re.SubexpNames() {
+			if m != nil && name != "" && m[i] != "" {
+				out[name] = m[i]
+			}
+		}
+	}
+	var specific [][2]string
+	for _, v := range d.labels {
+		i := strings.Index(v, "=")
+		key, value := v[:i], v[i+1:]
+		j := strings.LastIndex(key, ":")
+		if j < 0 {
+			out[key] = value
+			continue
+		}
+		pattern := key[:j]
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok || pattern == path {
+			specific = append(specific, [2]string{key[j+1:], value})
+		}
+	}
+	for _, kv := range specific {
+		out[kv[0]] = kv[1]
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// labelStats summarizes the results of a test under one label value.
+type labelStats struct {
+	runs     int
+	failures int
+	total    time.Duration
+}
+
+func (l *labelStats) mean() time.Duration {
+	return l.total / time.Duration(l.runs)
+}
+
+type comparisonRow struct {
+	pkg    string
+	test   string
+	values map[string]*labelStats
+	// ratio is the slowest mean duration over the fastest, across values.
+	ratio float64
+}
+
+// compareBy groups the test results by the value of the label key, skipping
+// results without it, and returns the values in order with a row per test.
+// Rows contrasting the values the most come first.
+func (s *stats) compareBy(key string) ([]string, []*comparisonRow) {
+	var values []string
+	seen := make(map[string]bool)
+	rows := make(map[id]*comparisonRow)
+	for _, r := range s.Results {
+		value, ok := r.Labels[key]
+		if r.Kind != "test" || !ok {
+			continue
+		}
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+		tid := testjson.TestID(r.Package, r.Test)
+		row, ok := rows[tid]
+		if !ok {
+			row = &comparisonRow{pkg: r.Package, test: r.Test, values: make(map[string]*labelStats)}
+			rows[tid] = row
+		}
+		ls, ok := row.values[value]
+		if !ok {
+			ls = &labelStats{}
+			row.values[value] = ls
+		}
+		ls.runs++
+		ls.total += fromSeconds(r.Duration)
+		if r.Status == "fail" {
+			ls.failures++
+		}
+	}
+	sort.Strings(values)
+
+	var out []*comparisonRow
+	for _, row := range rows {
+		var min, max time.Duration = -1, 0
+		for _, ls := range row.values {
+			if m := ls.mean(); min < 0 || m < min {
+				min = m
+			}
+			if m := ls.mean(); m > max {
+				max = m
+			}
+		}
+		if len(row.values) > 1 && min > 0 {
+			row.ratio = float64(max) / float64(min)
+		}
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ratio != out[j].ratio {
+			return out[i].ratio > out[j].ratio
+		}
+		return testjson.TestID(out[i].pkg, out[i].test) < testjson.TestID(out[j].pkg, out[j].test)
+	})
+	return values, out
+}
+
+// printComparison writes a header row naming the label values followed by
+// the mean duration and failures over runs of every test under each value,
+// `-` where it did not run, and the ratio of its slowest mean to its fastest.
+func printComparison(w io.Writer, s *stats, opts options) {
+	values, rows := s.compareBy(opts.compareBy)
+	header := []string{"test", "pkg"}
+	for _, v := range values {
+		header = append(header, opts.compareBy+"="+v)
+	}
+	fmt.Fprintln(w, strings.Join(append(header, "ratio"), "\t"))
+	for _, row := range rows {
+		cells := []string{row.test, row.pkg}
+		for _, v := range values {
+			ls, ok := row.values[v]
+			if !ok {
+				cells = append(cells, "-")
+				continue
+			}
+			cell := fmt.Sprintf("%s %d/%d", opts.dur(ls.mean()), ls.failures, ls.runs)
+			if ls.failures > 0 {
+				cell = opts.paint(colorRed, cell)
+			}
+			cells = append(cells, cell)
+		}
+		ratio := "-"
+		if row.ratio > 0 {
+			ratio = fmt.Sprintf("%.2fx", row.ratio)
+		}
+		fmt.Fprintln(w, strings.Join(append(cells, ratio), "\t"))
+	}
+}
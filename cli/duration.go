@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+)
+
+// durationFormats lists the values accepted by the `-duration-format` flag.
+var durationFormats = []string{"go", "s", "ms", "human"}
+
+func isDurationFormat(name string) bool {
+	for _, f := range durationFormats {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// dur formats d per `-duration-format`: Go's full precision (`1m23.456789s`),
+// plain seconds (`83.457`), plain milliseconds (`83457`), or rounded to
+// tenths of a second, or milliseconds below a second (`1m23.5s`, `236ms`).
+func (o options) dur(d time.Duration) string {
+	switch o.durationFormat {
+	case "s":
+		return fmt.Sprintf("%.3f", d.Seconds())
+	case "ms":
+		return fmt.Sprintf("%d", d.Round(time.Millisecond).Milliseconds())
+	case "human":
+		if d < time.Second && d > -time.Second {
+			return d.Round(time.Millisecond).String()
+		}
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+)
+
+// exitTestFailure is the exit code used by -fail-on-test-failure, the same go
+// test exits with when tests fail.
+const exitTestFailure = 1
+
+// testFailures lists the packages and tests whose latest result failed.
+func (s *stats) testFailures() []string {
+	var pkgs, tests []string
+	for _, p := range s.Packages {
+		if !p.Passed {
+			pkgs = append(pkgs, fmt.Sprintf("fail\tpkg\t%s", p.ID))
+		}
+	}
+	for _, t := range s.Tests {
+		if !t.Passed {
+			tests = append(tests, fmt.Sprintf("fail\ttest\t%s\t%s", t.Name, t.Package))
+		}
+	}
+	sort.Strings(pkgs)
+	sort.Strings(tests)
+	return append(pkgs, tests...)
+}
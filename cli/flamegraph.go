@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// writeFlamegraph writes folded stacks (`pkg;Test;Subtest value`) as consumed
+// by flamegraph.pl and speedscope. Values are self time in microseconds: a
+// frame's own duration minus that of the frames directly beneath it.
+func writeFlamegraph(w io.Writer, s *stats) error {
+	children := make(map[string]time.Duration)
+	for _, t := range s.Tests {
+		parent := t.Package
+		if p := parentTest(t.Name); p != "" {
+			parent = testjson.TestID(t.Package, p)
+		}
+		children[parent] += t.Duration
+	}
+
+	folded := make(map[string]int64)
+	add := func(stack string, total time.Duration, key string) {
+		self := total - children[key]
+		if self > 0 {
+			folded[stack] += self.Microseconds()
+		}
+	}
+	for _, p := range s.Packages {
+		add(flameFrame(p.ID), p.Duration, p.ID)
+	}
+	for k, t := range s.Tests {
+		frames := []string{flameFrame(t.Package)}
+		for _, part := range strings.Split(t.Name, "/") {
+			frames = append(frames, flameFrame(part))
+		}
+		add(strings.Join(frames, ";"), t.Duration, k)
+	}
+
+	var stacks []string
+	for stack, v := range folded {
+		if v > 0 {
+			stacks = append(stacks, stack)
+		}
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, folded[stack]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flameFrame keeps the folded format's separators out of frame names.
+func flameFrame(name string) string {
+	return strings.NewReplacer(";", ":", " ", "_").Replace(name)
+}
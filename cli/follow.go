@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// followPoll is how long to wait for a followed file to grow.
+const followPoll = 200 * time.Millisecond
+
+// clearScreen moves the cursor home and clears a VT100-compatible terminal.
+const clearScreen = "\033[H\033[2J"
+
+// tailLines sends the lines of path to out as they are written, until the
+// input ends. Stdin ("-") ends at EOF; a file is followed until done closes.
+func tailLines(path string, out chan<- string, done <-chan struct{}) error {
+	defer close(out)
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r := bufio.NewReader(in)
+	var partial strings.Builder
+	for {
+		chunk, err := r.ReadString('\n')
+		partial.WriteString(chunk)
+		if err == nil {
+			select {
+			case out <- partial.String():
+			case <-done:
+				return nil
+			}
+			partial.Reset()
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		if path == "-" {
+			if partial.Len() > 0 {
+				out <- partial.String()
+			}
+			return nil
+		}
+		select {
+		case <-time.After(followPoll):
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// follow accumulates events from a file that is still being written, calling
+// redraw every interval and once more when the input ends. Only stdin ends;
+// a file is followed until the process is interrupted.
+func follow(path string, s *stats, interval time.Duration, redraw func()) error {
+	lines := make(chan string, 1024)
+	done := make(chan struct{})
+	defer close(done)
+	errs := make(chan error, 1)
+	go func() { errs <- tailLines(path, lines, done) }()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	n, skipped := 0, 0
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				redraw()
+				if skipped > 0 {
+					logWarn("skipped unparseable lines, use -strict to fail instead", "file", path, "lines", skipped)
+				}
+				return <-errs
+			}
+			n++
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			var rawLine testjson.RawLine
+			if err := json.Unmarshal([]byte(line), &rawLine); err != nil {
+				if s.read.strict {
+					return fmt.Errorf("%s: line %d: %v", path, n, err)
+				}
+				// A followed file does not end, so its first skip is
+				// logged as it happens.
+				if skipped++; skipped == 1 && path != "-" {
+					logWarn("skipping unparseable lines, use -strict to fail instead", "file", path, "line", n)
+				}
+				continue
+			}
+			s.add(rawLine)
+		case <-ticker.C:
+			redraw()
+		}
+	}
+}
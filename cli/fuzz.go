@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+const failingInputMarker = "Failing input written to "
+
+type fuzzTarget struct {
+	pkg      pkgid
+	name     string
+	duration time.Duration
+	seeds    int
+	crashers []string
+}
+
+func isFuzzTarget(name string) bool {
+	return strings.HasPrefix(name, "Fuzz")
+}
+
+// splitFuzzName splits a test name such as `FuzzParse/seed#0` into the fuzz
+// target and the seed corpus entry. The entry is empty for the target itself.
+func splitFuzzName(name string) (target string, entry string) {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+func (s *stats) fuzzTarget(pkg pkgid, name string) *fuzzTarget {
+	key := testjson.TestID(pkg, name)
+	f, ok := s.fuzz[key]
+	if !ok {
+		f = &fuzzTarget{pkg: pkg, name: name}
+		s.fuzz[key] = f
+	}
+	return f
+}
+
+func (s *stats) recordFuzz(line testjson.RawLine) {
+	if !isFuzzTarget(line.Test) {
+		return
+	}
+	target, entry := splitFuzzName(line.Test)
+	switch line.Action {
+	case "pass", "fail":
+		f := s.fuzzTarget(line.Package, target)
+		if entry == "" {
+			f.duration = time.Duration(line.Elapsed * float64(time.Second))
+			return
+		}
+		f.seeds++
+		if line.Action == "fail" {
+			f.addCrasher(corpusPath(target, entry))
+		}
+	case "output":
+		out := strings.TrimSpace(line.Output)
+		if strings.HasPrefix(out, failingInputMarker) {
+			f := s.fuzzTarget(line.Package, target)
+			f.addCrasher(strings.TrimPrefix(out, failingInputMarker))
+		}
+	}
+}
+
+// corpusPath names a failing seed corpus entry the same way `go test` reports
+// newly found crashers. Entries added with f.Add have no file of their own.
+func corpusPath(target, entry string) string {
+	if strings.HasPrefix(entry, "seed#") {
+		return entry
+	}
+	return "testdata/fuzz/" + target + "/" + entry
+}
+
+func (f *fuzzTarget) addCrasher(c string) {
+	for _, x := range f.crashers {
+		if x == c {
+			return
+		}
+	}
+	f.crashers = append(f.crashers, c)
+}
+
+func (s *stats) fuzzTargetsSortedByDurationDescending() []*fuzzTarget {
+	var out []*fuzzTarget
+	for _, f := range s.fuzz {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].duration != out[j].duration {
+			return out[j].duration < out[i].duration
+		}
+		return testjson.TestID(out[i].pkg, out[i].name) < testjson.TestID(out[j].pkg, out[j].name)
+	})
+	return out
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+const (
+	// gapNoTestFiles is a package without any _test.go files.
+	gapNoTestFiles = "no-test-files"
+	// gapNoTestsRun is a package whose result came without any test
+	// running, such as one with only helpers in its _test.go files.
+	gapNoTestsRun = "no-tests-run"
+)
+
+type coverageGap struct {
+	pkg    pkgid
+	reason string
+}
+
+// recordGap counts the test events of each package run and, at its result,
+// notes passing packages that ran no tests. A later run of the package that does
+// run tests clears the gap.
+func (s *stats) recordGap(line testjson.RawLine) {
+	if line.Test != "" {
+		s.pkgTestEvents[line.Package]++
+		return
+	}
+	switch line.Action {
+	case "start":
+		delete(s.pkgTestEvents, line.Package)
+	case "output":
+		if strings.HasSuffix(strings.TrimRight(line.Output, "\n"), "[no test files]") {
+			s.gaps[line.Package] = gapNoTestFiles
+		}
+	case "skip":
+		s.gaps[line.Package] = gapNoTestFiles
+	case "pass":
+		if s.pkgTestEvents[line.Package] > 0 {
+			delete(s.gaps, line.Package)
+		} else if s.gaps[line.Package] != gapNoTestFiles {
+			s.gaps[line.Package] = gapNoTestsRun
+		}
+		delete(s.pkgTestEvents, line.Package)
+	case "fail":
+		// Packages failing without running tests failed to build or set
+		// up, which the build-failures and crashes statistics report.
+		delete(s.pkgTestEvents, line.Package)
+	}
+}
+
+// coverageGapsSorted lists the packages that ran no tests, by reason then
+// package.
+func (s *stats) coverageGapsSorted() []coverageGap {
+	var out []coverageGap
+	for p, reason := range s.gaps {
+		out = append(out, coverageGap{pkg: p, reason: reason})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].reason != out[j].reason {
+			return out[i].reason < out[j].reason
+		}
+		return out[i].pkg < out[j].pkg
+	})
+	return out
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ghaDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	ghaPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// ghaCommand formats a GitHub Actions workflow command such as `::error`.
+func ghaCommand(command, title, message string) string {
+	return fmt.Sprintf("::%s title=%s::%s\n", command, ghaPropertyEscaper.Replace(title), ghaDataEscaper.Replace(message))
+}
+
+// writeGitHubActions annotates failed tests as errors, tests over
+// `-fail-if-test-over` as errors, tests over `-slow-threshold` as warnings,
+// and summarizes the run in a notice.
+func writeGitHubActions(w io.Writer, s *stats, opts options) error {
+	var failed, slow int
+	tests := s.TestsByDuration()
+	for _, t := range tests {
+		var cmd string
+		switch {
+		case !t.Passed:
+			failed++
+			cmd = ghaCommand("error", t.Name+" failed", fmt.Sprintf("%s in %s failed after %s", t.Name, t.Package, opts.dur(t.Duration)))
+		case opts.testOver > 0 && t.Duration > opts.testOver:
+			slow++
+			cmd = ghaCommand("error", t.Name+" too slow", fmt.Sprintf("%s in %s took %s, over the %s limit", t.Name, t.Package, opts.dur(t.Duration), opts.dur(opts.testOver)))
+		case opts.slowThreshold > 0 && t.Duration > opts.slowThreshold:
+			slow++
+			cmd = ghaCommand("warning", t.Name+" is slow", fmt.Sprintf("%s in %s took %s, over %s", t.Name, t.Package, opts.dur(t.Duration), opts.dur(opts.slowThreshold)))
+		default:
+			continue
+		}
+		if _, err := io.WriteString(w, cmd); err != nil {
+			return err
+		}
+	}
+	summary := fmt.Sprintf("%d tests in %d packages, %d failed, %d slow", len(tests), len(s.Packages), failed, slow)
+	if len(tests) > 0 {
+		summary += fmt.Sprintf("; slowest %s (%s)", tests[0].Name, opts.dur(tests[0].Duration))
+	}
+	_, err := io.WriteString(w, ghaCommand("notice", "goteststats", summary))
+	return err
+}
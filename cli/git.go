@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitMeta identifies the commit a run tested.
+type gitMeta struct {
+	commit string
+	branch string
+	time   time.Time
+}
+
+// gitTags holds the -commit, -branch and -commit-time flags, each naming a
+// value for every file or for file=value, and the metadata detected with
+// -git for files they leave unset.
+type gitTags struct {
+	commits  runLabels
+	branches runLabels
+	times    runLabels
+	detected gitMeta
+}
+
+// enabled reports whether runs are tagged at all.
+func (g *gitTags) enabled() bool {
+	return g.detected.commit != "" || len(g.commits) > 0 || len(g.branches) > 0 || len(g.times) > 0
+}
+
+// of returns the metadata of the run read from path.
+func (g *gitTags) of(path string) gitMeta {
+	m := g.detected
+	if v, ok := g.commits.lookup(path); ok {
+		m.commit = v
+	}
+	if v, ok := g.branches.lookup(path); ok {
+		m.branch = v
+	}
+	if v, ok := g.times.lookup(path); ok {
+		// Validated by check.
+		m.time, _ = parseDate(v)
+	}
+	return m
+}
+
+// check validates the -commit-time values.
+func (g *gitTags) check() error {
+	for _, v := range g.times {
+		if i := strings.LastIndex(v, "="); i >= 0 {
+			v = v[i+1:]
+		}
+		if _, err := parseDate(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDate accepts RFC 3339 timestamps and plain dates.
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want RFC 3339 or YYYY-MM-DD", v)
+	}
+	return t, nil
+}
+
+// detectGit reads the checked out commit, branch and commit time of the git
+// repository in the working directory.
+func detectGit() (gitMeta, error) {
+	out, err := exec.Command("git", "show", "-s", "--format=%H%n%cI", "HEAD").Output()
+	if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+		return gitMeta{}, fmt.Errorf("detecting git commit: %s", strings.TrimSpace(string(ee.Stderr)))
+	} else if err != nil {
+		return gitMeta{}, fmt.Errorf("detecting git commit: %v", err)
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(fields) != 2 {
+		return gitMeta{}, fmt.Errorf("detecting git commit: unexpected output %q", out)
+	}
+	m := gitMeta{commit: fields[0]}
+	if m.time, err = time.Parse(time.RFC3339, fields[1]); err != nil {
+		return gitMeta{}, fmt.Errorf("detecting git commit: %v", err)
+	}
+	// A detached HEAD has no branch.
+	if out, err := exec.Command("git", "symbolic-ref", "--short", "-q", "HEAD").Output(); err == nil {
+		m.branch = strings.TrimSpace(string(out))
+	}
+	return m, nil
+}
+
+// shortCommit abbreviates a commit hash for reports.
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
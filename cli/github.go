@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// githubMarker identifies the sticky comment so later runs update it.
+const githubMarker = "<!-- goteststats -->"
+
+type githubClient struct {
+	api   string
+	token string
+	repo  string
+}
+
+func (c *githubClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.api, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("github: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type githubComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// upsertComment updates the pull request comment carrying githubMarker, or
+// creates one if there is none yet.
+func (c *githubClient) upsertComment(pr int, body string) error {
+	body = githubMarker + "\n" + body
+	for page := 1; ; page++ {
+		var comments []githubComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100&page=%d", c.repo, pr, page)
+		if err := c.do(http.MethodGet, path, nil, &comments); err != nil {
+			return err
+		}
+		for _, cm := range comments {
+			if strings.Contains(cm.Body, githubMarker) {
+				path := fmt.Sprintf("/repos/%s/issues/comments/%d", c.repo, cm.ID)
+				return c.do(http.MethodPatch, path, map[string]string{"body": body}, nil)
+			}
+		}
+		if len(comments) < 100 {
+			break
+		}
+	}
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", c.repo, pr)
+	return c.do(http.MethodPost, path, map[string]string{"body": body}, nil)
+}
+
+func (c *githubClient) createCheckRun(sha string, passed bool, summary string) error {
+	conclusion := "success"
+	if !passed {
+		conclusion = "failure"
+	}
+	return c.do(http.MethodPost, fmt.Sprintf("/repos/%s/check-runs", c.repo), map[string]interface{}{
+		"name":       "goteststats",
+		"head_sha":   sha,
+		"status":     "completed",
+		"conclusion": conclusion,
+		"output": map[string]string{
+			"title":   "Test statistics",
+			"summary": summary,
+		},
+	}, nil)
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func publishGitHub(args []string) {
+	fs := flag.NewFlagSet("publish github", flag.ExitOnError)
+	c := &githubClient{}
+	fs.StringVar(&c.api, "api", envOr("GITHUB_API_URL", "https://api.github.com"), "GitHub API base `url`")
+	fs.StringVar(&c.token, "token", os.Getenv("GITHUB_TOKEN"), "GitHub token (default $GITHUB_TOKEN)")
+	fs.StringVar(&c.repo, "repo", os.Getenv("GITHUB_REPOSITORY"), "Repository as `owner/name` (default $GITHUB_REPOSITORY)")
+	pr := fs.Int("pr", 0, "Pull request `number` to comment on")
+	checkRun := fs.Bool("check-run", false, "Publish a check run on -sha instead of a pull request comment")
+	sha := fs.String("sha", os.Getenv("GITHUB_SHA"), "Commit the check run is attached to (default $GITHUB_SHA)")
+	top := fs.Int("top", 10, "Number of slowest tests and regressions to list")
+	var base fileList
+	fs.Var(&base, "base", "Comma-separated `files` from the base branch, go test -json output or CSV durations, to report regressions against")
+	var opts options
+	fs.DurationVar(&opts.regressionFloor, "regression-floor", 100*time.Millisecond, "Ignore slowdowns against -base smaller than this duration")
+	fs.Var(&opts.failOnRegression, "fail-on-regression", "Only report tests slower than -base by more than this `percentage`, and fail the check run on them")
+	fs.StringVar(&opts.durationFormat, "duration-format", "human", "Duration `format` in the report: "+strings.Join(durationFormats, "|"))
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats publish github [flags] [file1.json ... fileN.json]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	switch {
+	case c.token == "" || c.repo == "":
+		fatal("publish github: -token and -repo are required")
+	case *checkRun && *sha == "":
+		fatal("publish github: -check-run requires -sha")
+	case !*checkRun && *pr == 0:
+		fatal("publish github: -pr is required")
+	case !isDurationFormat(opts.durationFormat):
+		fatalf("publish github: -duration-format must be one of `%s`", strings.Join(durationFormats, "`, `"))
+	}
+
+	s := newStatsFromFiles(fs.Args(), nil)
+	var baseStats *stats
+	if len(base) > 0 {
+		baseStats = newStats()
+		baseStats.addBaseline(base)
+		opts.base = baseStats
+	}
+	report := markdownReport(s, baseStats, *top, opts)
+
+	var err error
+	if *checkRun {
+		// Packages can fail without a failed test, as on a panic in
+		// TestMain or a timeout, and fail to build without any result.
+		passed := len(s.testFailures()) == 0 && len(s.BuildFailures) == 0 && len(s.regressionViolations(opts)) == 0
+		err = c.createCheckRun(*sha, passed, report)
+	} else {
+		err = c.upsertComment(*pr, report)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
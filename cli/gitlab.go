@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+type junitReport struct {
+	XMLName  xml.Name            `xml:"testsuites"`
+	Tests    int                 `xml:"tests,attr"`
+	Failures int                 `xml:"failures,attr"`
+	Skipped  int                 `xml:"skipped,attr"`
+	Time     string              `xml:"time,attr"`
+	Suites   []*junitReportSuite `xml:"testsuite"`
+}
+
+type junitReportSuite struct {
+	Name      string            `xml:"name,attr"`
+	Tests     int               `xml:"tests,attr"`
+	Failures  int               `xml:"failures,attr"`
+	Skipped   int               `xml:"skipped,attr"`
+	Time      string            `xml:"time,attr"`
+	Timestamp string            `xml:"timestamp,attr,omitempty"`
+	Cases     []junitReportCase `xml:"testcase"`
+	duration  float64
+}
+
+type junitReportCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+func junitSeconds(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
+
+// writeJUnit writes the report cases as JUnit XML with a test suite per
+// package, the format GitLab reads from `artifacts:reports:junit`.
+func writeJUnit(w io.Writer, cases []reportCase) error {
+	report := &junitReport{}
+	suites := make(map[string]*junitReportSuite)
+	var total float64
+	for _, c := range cases {
+		suite, ok := suites[c.pkg]
+		if !ok {
+			suite = &junitReportSuite{Name: c.pkg, Timestamp: c.start.UTC().Format("2006-01-02T15:04:05")}
+			suites[c.pkg] = suite
+			report.Suites = append(report.Suites, suite)
+		}
+		jc := junitReportCase{Name: c.name, Classname: c.pkg, Time: junitSeconds(c.duration)}
+		switch c.status {
+		case "fail":
+			jc.Failure = &junitMessage{Message: c.message, Text: c.details}
+			suite.Failures++
+			report.Failures++
+		case "skip":
+			jc.Skipped = &junitMessage{Message: c.message}
+			suite.Skipped++
+			report.Skipped++
+		}
+		suite.Cases = append(suite.Cases, jc)
+		suite.Tests++
+		report.Tests++
+		suite.duration += c.duration
+		total += c.duration
+	}
+	for _, suite := range report.Suites {
+		suite.Time = junitSeconds(suite.duration)
+	}
+	report.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func publishGitLab(args []string) {
+	fs := flag.NewFlagSet("publish gitlab", flag.ExitOnError)
+	out := fs.String("o", "", "Write the report to this `file` instead of stdout")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats publish gitlab [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Writes a JUnit XML unit test report for GitLab to collect with artifacts:reports:junit.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	s := newStats()
+	s.CaptureOutput = true
+	s.addFiles(fs.Args())
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeJUnit(w, reportCases(s)); err != nil {
+		fatal(err)
+	}
+}
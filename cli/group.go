@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// groupings lists the values accepted by the `-group-by` flag.
+var groupings = []string{"pkg"}
+
+type testGroup struct {
+	pkg      pkgid
+	subtotal time.Duration
+	passed   bool
+	tests    []*testjson.Test
+}
+
+// groupTestsByPackage groups tests, kept in their given order, under their
+// package. Groups are ordered by subtotal, slowest first. Subtests run within
+// their parent, so only top-level tests count towards the subtotal.
+func groupTestsByPackage(tests []*testjson.Test) []*testGroup {
+	byPkg := make(map[pkgid]*testGroup)
+	var groups []*testGroup
+	for _, t := range tests {
+		g, ok := byPkg[t.Package]
+		if !ok {
+			g = &testGroup{pkg: t.Package, passed: true}
+			byPkg[t.Package] = g
+			groups = append(groups, g)
+		}
+		g.tests = append(g.tests, t)
+		if parentTest(t.Name) == "" {
+			g.subtotal += t.Duration
+		}
+		g.passed = g.passed && t.Passed
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].subtotal != groups[j].subtotal {
+			return groups[j].subtotal < groups[i].subtotal
+		}
+		return groups[i].pkg < groups[j].pkg
+	})
+	return groups
+}
+
+// printTestsByPackage prints a subtotal row per package followed by its tests,
+// indented.
+func printTestsByPackage(w io.Writer, tests []*testjson.Test, opts options) {
+	for _, g := range groupTestsByPackage(tests) {
+		line := fmt.Sprintf("%s\t%s\t%d tests", g.pkg, opts.dur(g.subtotal), len(g.tests))
+		fmt.Fprintln(w, opts.paint(opts.statusColor(g.passed, 0), line))
+		for _, t := range g.tests {
+			line := fmt.Sprintf("    %s\t%s\t%s", t.Name, opts.dur(t.Duration), statusOf(t.Passed))
+			fmt.Fprintln(w, opts.paint(opts.statusColor(t.Passed, t.Duration), line))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+// heatmapModes lists the values accepted by the `-heatmap-by` flag.
+var heatmapModes = []string{"duration", "status"}
+
+// heatmapRamp draws durations as bar heights, and heatmapColors colors
+// them from green to red in the 256-color palette.
+var (
+	heatmapRamp   = []rune("▁▂▃▄▅▆▇█")
+	heatmapColors = []string{"38;5;46", "38;5;82", "38;5;118", "38;5;154", "38;5;190", "38;5;226", "38;5;214", "38;5;208", "38;5;202", "38;5;196"}
+)
+
+// heatmapLevels places the duration of each passing cell of a row between 0
+// for the fastest and 1 for the slowest passing run of the test, so drifts
+// show whatever the test's typical duration.
+func heatmapLevels(row *matrixRow) map[string]float64 {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, r := range row.cells {
+		if r.Status == "pass" {
+			min = math.Min(min, r.Duration)
+			max = math.Max(max, r.Duration)
+		}
+	}
+	out := make(map[string]float64)
+	for run, r := range row.cells {
+		if r.Status == "pass" && max > min {
+			out[run] = (r.Duration - min) / (max - min)
+		}
+	}
+	return out
+}
+
+func heatmapIndex(level float64, n int) int {
+	return int(math.Min(level*float64(n), float64(n-1)))
+}
+
+// printHeatmap writes a row per test with a character per run, in the order
+// the runs were read: by default a bar as high as the duration relative to
+// the test's other runs, or with -heatmap-by status a mark for the outcome.
+// Failures are marked X and skips s in both modes.
+func printHeatmap(w io.Writer, s *stats, opts options) {
+	runs, rows := s.runMatrix()
+	if len(runs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "test\tpkg\t%d runs, %s to %s\n", len(runs), runs[0], runs[len(runs)-1])
+	for _, row := range rows {
+		levels := heatmapLevels(row)
+		var b strings.Builder
+		for _, run := range runs {
+			r, ok := row.cells[run]
+			switch {
+			case !ok:
+				b.WriteString(" ")
+			case r.Status == "fail":
+				b.WriteString(opts.paint(colorRed, "X"))
+			case r.Status == "skip":
+				b.WriteString(opts.paint(colorYellow, "s"))
+			case opts.heatmapBy == "status":
+				b.WriteString(opts.paint(colorGreen, "."))
+			default:
+				level := levels[run]
+				bar := string(heatmapRamp[heatmapIndex(level, len(heatmapRamp))])
+				b.WriteString(opts.paint(heatmapColors[heatmapIndex(level, len(heatmapColors))], bar))
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", row.test, row.pkg, b.String())
+	}
+}
+
+// writeHeatmapHTML renders the heatmap as an HTML table with a column per
+// run, cells shaded from green to red by relative duration or colored by
+// status, and the run, duration and status of each cell on hover.
+func writeHeatmapHTML(w io.Writer, s *stats, opts options) error {
+	runs, rows := s.runMatrix()
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>goteststats heatmap</title>
+<style>
+body { font: 12px sans-serif; }
+table { border-collapse: collapse; }
+th, td { padding: 0 6px; white-space: nowrap; }
+td.cell { width: 12px; min-width: 12px; padding: 0; border: 1px solid #fff; text-align: center; color: #fff; }
+th.run { writing-mode: vertical-rl; transform: rotate(180deg); font-weight: normal; }
+</style>
+</head>
+<body>
+<table>
+<tr><th>test</th><th>pkg</th>`)
+	for _, run := range runs {
+		fmt.Fprintf(&b, `<th class="run">%s</th>`, html.EscapeString(run))
+	}
+	b.WriteString("</tr>\n")
+	for _, row := range rows {
+		levels := heatmapLevels(row)
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td>", html.EscapeString(row.test), html.EscapeString(row.pkg))
+		for _, run := range runs {
+			r, ok := row.cells[run]
+			if !ok {
+				b.WriteString(`<td class="cell" style="background:#eee"></td>`)
+				continue
+			}
+			var color, mark string
+			switch {
+			case r.Status == "fail":
+				color, mark = "#b71c1c", "✗"
+			case r.Status == "skip":
+				color = "#fbc02d"
+			case opts.heatmapBy == "status":
+				color = "#43a047"
+			default:
+				color = fmt.Sprintf("hsl(%.0f, 70%%, 50%%)", 120*(1-levels[run]))
+			}
+			title := fmt.Sprintf("%s: %s %s", run, opts.dur(fromSeconds(r.Duration)), r.Status)
+			fmt.Fprintf(&b, `<td class="cell" style="background:%s" title="%s">%s</td>`, color, html.EscapeString(title), mark)
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
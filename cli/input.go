@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// readCloser pairs a decompressing reader with the file underneath it.
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r *readCloser) Close() error {
+	return r.close()
+}
+
+// openInput opens a local file or remote URL for reading, transparently
+// decompressing gzip and zstd content. Compression is detected from the magic
+// bytes rather than the extension, so `.gz` and `.zst` inputs work whatever
+// they are named.
+func openInput(path string) (io.ReadCloser, error) {
+	var f io.ReadCloser
+	var err error
+	if isRemote(path) {
+		f, err = openRemote(path)
+	} else {
+		f, err = os.Open(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	magic, _ := r.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &readCloser{gz, func() error {
+			gz.Close()
+			return f.Close()
+		}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &readCloser{zr, func() error {
+			zr.Close()
+			return f.Close()
+		}}, nil
+	}
+	return &readCloser{r, f.Close}, nil
+}
+
+// inputExtensions are the file suffixes picked up when walking a directory,
+// also when compressed as `.gz` or `.zst`. Their format is detected from
+// their content, so that logs of go test -json output and JUnit XML reports
+// such as Bazel's test.xml files are read along with `.json` files.
+var inputExtensions = []string{".json", ".jsonl", ".ndjson", ".xml", ".log", ".txt"}
+
+// jsonExtensions are the suffixes of inputExtensions that name go test JSON,
+// read as such when their content does not tell, as when the first line is
+// longer than what is sniffed.
+var jsonExtensions = inputExtensions[:3]
+
+func isInputFile(name string) bool {
+	return hasExtension(name, inputExtensions)
+}
+
+// hasExtension reports whether name ends in one of exts, also when
+// compressed.
+func hasExtension(name string, exts []string) bool {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".zst")
+	for _, ext := range exts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandInputs resolves arguments into input files: directories are walked
+// recursively for inputExtensions files and glob patterns are expanded, with
+// `**` matching any number of directories. Other arguments are kept as is.
+func expandInputs(args []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	for _, arg := range args {
+		if isRemote(arg) {
+			add(arg)
+			continue
+		}
+		var matches []string
+		var err error
+		switch info, statErr := os.Stat(arg); {
+		case statErr == nil && info.IsDir():
+			matches, err = walkInputs(arg, func(rel string) bool { return isInputFile(rel) })
+		case statErr != nil && strings.ContainsAny(arg, "*?["):
+			matches, err = globInputs(arg)
+		default:
+			add(arg)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	return out, nil
+}
+
+// walkInputs lists the regular files below root whose slash-separated path
+// relative to root satisfies keep, in lexical order.
+func walkInputs(root string, keep func(rel string) bool) ([]string, error) {
+	var out []string
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if keep(filepath.ToSlash(rel)) {
+			out = append(out, p)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func globInputs(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(pattern)
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(filepath.FromSlash(pattern))
+	}
+	// Walk from the longest directory prefix free of metacharacters.
+	parts := strings.Split(pattern, "/")
+	i := 0
+	for i < len(parts)-1 && !strings.ContainsAny(parts[i], "*?[") {
+		i++
+	}
+	root := strings.Join(parts[:i], "/")
+	if root == "" {
+		root = "."
+		if strings.HasPrefix(pattern, "/") {
+			root = "/"
+		}
+	}
+	rest := parts[i:]
+	if _, err := os.Stat(root); err != nil {
+		return nil, nil
+	}
+	return walkInputs(filepath.FromSlash(root), func(rel string) bool {
+		return matchSegments(rest, strings.Split(rel, "/"))
+	})
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// `**` segment matches zero or more path segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], segments[0])
+	return err == nil && ok && matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,18 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// writeJSONL writes every result as one JSON object per line, in the order
+// they were ingested.
+func writeJSONL(w io.Writer, s *stats) error {
+	enc := json.NewEncoder(w)
+	for _, r := range s.Results {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
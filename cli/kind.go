@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// kindNames lists the kinds of testKinds in lower case, as accepted by the
+// `-kind` flag.
+func kindNames() []string {
+	var out []string
+	for _, k := range testKinds {
+		out = append(out, strings.ToLower(k))
+	}
+	return out
+}
+
+// testKind classifies a test, or a subtest by its top-level test, by the
+// name prefix go test requires of each kind of function.
+func testKind(name string) string {
+	for _, k := range testKinds {
+		if strings.HasPrefix(name, k) {
+			return strings.ToLower(k)
+		}
+	}
+	return "test"
+}
+
+// parseKinds parses the comma-separated `-kind` flag into a set, nil if the
+// flag is empty.
+func parseKinds(spec string) (map[string]bool, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	out := make(map[string]bool)
+	for _, k := range strings.Split(spec, ",") {
+		k = strings.TrimSpace(k)
+		valid := false
+		for _, name := range kindNames() {
+			valid = valid || k == name
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown kind %q", k)
+		}
+		out[k] = true
+	}
+	return out, nil
+}
+
+// openBenchmark is the benchmark a package is running.
+type openBenchmark struct {
+	name  string
+	start time.Time
+}
+
+// recordBenchTime times benchmarks, which get a run event but no result: a
+// benchmark lasts until the next test of its package starts or the package
+// finishes.
+func (s *stats) recordBenchTime(line testjson.RawLine) {
+	open, ok := s.openBenchmarks[line.Package]
+	ends := line.Action == "run" || line.Test == "" && testjson.IsTerminal(line.Action)
+	if ok && ends {
+		s.benchTimes[testjson.TestID(line.Package, open.name)] += line.Time.Sub(open.start)
+		delete(s.openBenchmarks, line.Package)
+	}
+	if line.Action == "run" && testKind(line.Test) == "benchmark" && !strings.Contains(line.Test, "/") {
+		s.openBenchmarks[line.Package] = openBenchmark{name: line.Test, start: line.Time}
+	}
+}
+
+type kindSummary struct {
+	kind  string
+	count int
+	total time.Duration
+}
+
+// kindSummaries counts the top-level tests of each kind and totals their
+// time, leaving out subtests whose time their parents already include.
+func (s *stats) kindSummaries() []*kindSummary {
+	byKind := make(map[string]*kindSummary)
+	var out []*kindSummary
+	for _, k := range kindNames() {
+		byKind[k] = &kindSummary{kind: k}
+		out = append(out, byKind[k])
+	}
+	for _, t := range s.Tests {
+		if strings.Contains(t.Name, "/") {
+			continue
+		}
+		ks := byKind[testKind(t.Name)]
+		ks.count++
+		ks.total += t.Duration
+	}
+	for key, d := range s.benchTimes {
+		if _, ok := s.Tests[key]; ok {
+			continue
+		}
+		ks := byKind["benchmark"]
+		ks.count++
+		ks.total += d
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// runLabels is a repeatable flag naming runs: `label` names every file,
+// `file=label` a file given by path or base name pattern. Files without a
+// label are named after their path.
+type runLabels []string
+
+func (l *runLabels) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *runLabels) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func (l runLabels) of(path string) string {
+	if label, ok := l.lookup(path); ok {
+		return label
+	}
+	return path
+}
+
+// lookup returns the label given for path, if any.
+func (l runLabels) lookup(path string) (string, bool) {
+	label, found := "", false
+	for _, v := range l {
+		i := strings.LastIndex(v, "=")
+		if i < 0 {
+			label, found = v, true
+			continue
+		}
+		pattern := v[:i]
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok || pattern == path {
+			return v[i+1:], true
+		}
+	}
+	return label, found
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+type startLatency struct {
+	test    *testjson.Test
+	latency time.Duration
+}
+
+// startLatenciesSortedDescending measures how long after its package started
+// each top-level test began running. Large latencies for short tests point at
+// tests queued behind sequential ones, for example for lack of t.Parallel().
+func (s *stats) startLatenciesSortedDescending() []startLatency {
+	var out []startLatency
+	for _, t := range s.Tests {
+		p, ok := s.Packages[t.Package]
+		if !ok || parentTest(t.Name) != "" || t.Start.Before(p.Start) {
+			continue
+		}
+		out = append(out, startLatency{test: t, latency: t.Start.Sub(p.Start)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].latency != out[j].latency {
+			return out[j].latency < out[i].latency
+		}
+		return testjson.TestID(out[i].test.Package, out[i].test.Name) < testjson.TestID(out[j].test.Package, out[j].test.Name)
+	})
+	return out
+}
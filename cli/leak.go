@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// leakSignatures match output lines of common leak detectors such as goleak
+// and the checks of the net/http tests, by kind of leaked resource.
+var leakSignatures = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"goroutine", regexp.MustCompile(`found unexpected goroutines|(?i)leaked goroutines?|Too many goroutines running after|Test appears to have leaked`)},
+	{"fd", regexp.MustCompile(`(?i)leaked (file descriptors?|fds?)|(file descriptor|fd) leak`)},
+	{"resource", regexp.MustCompile(`(?i)leaked (connections?|sockets?|listeners?|files?)\b`)},
+}
+
+// leakCreator finds the test that started a leaked goroutine in the
+// `created by` lines of the goroutine dumps leak detectors print.
+var leakCreator = regexp.MustCompile(`created by \S*\.((?:Test|Benchmark|Fuzz)[^.\s(]*)`)
+
+type leak struct {
+	kind    string
+	pkg     pkgid
+	test    string
+	message string
+	count   int
+}
+
+// recordLeak collects leak detector reports. Reports printed outside any
+// test, as by goleak.VerifyTestMain, are attributed to the test that
+// created the first leaked goroutine listed, if any.
+func (s *stats) recordLeak(line testjson.RawLine) {
+	if line.Test == "" && testjson.IsTerminal(line.Action) {
+		s.closeLeak(line.Package)
+		return
+	}
+	if line.Action != "output" {
+		return
+	}
+	out := strings.ReplaceAll(strings.TrimSpace(line.Output), "\t", " ")
+	if open := s.openLeaks[line.Package]; open != nil {
+		if m := leakCreator.FindStringSubmatch(out); m != nil {
+			delete(s.openLeaks, line.Package)
+			s.addLeak(open.kind, open.pkg, m[1], open.message)
+			return
+		}
+	}
+	for _, sig := range leakSignatures {
+		if !sig.re.MatchString(out) {
+			continue
+		}
+		if line.Test == "" && sig.kind == "goroutine" {
+			s.closeLeak(line.Package)
+			s.openLeaks[line.Package] = &leak{kind: sig.kind, pkg: line.Package, message: out}
+			return
+		}
+		s.addLeak(sig.kind, line.Package, line.Test, out)
+		return
+	}
+}
+
+// closeLeak records the open report of a package without attributing it to
+// a test.
+func (s *stats) closeLeak(pkg pkgid) {
+	if open := s.openLeaks[pkg]; open != nil {
+		delete(s.openLeaks, pkg)
+		s.addLeak(open.kind, open.pkg, "", open.message)
+	}
+}
+
+func leakKey(kind string, pkg pkgid, test string) string {
+	return kind + "\n" + testjson.TestID(pkg, test)
+}
+
+func (s *stats) addLeak(kind string, pkg pkgid, test, message string) {
+	key := leakKey(kind, pkg, test)
+	l, ok := s.leaks[key]
+	if !ok {
+		l = &leak{kind: kind, pkg: pkg, test: test, message: message}
+		s.leaks[key] = l
+	}
+	l.count++
+}
+
+// leaksSorted lists the leaks by package and test, including reports still
+// waiting for the test that caused them, counted as closeLeak would.
+func (s *stats) leaksSorted() []*leak {
+	byKey := make(map[string]*leak)
+	for key, l := range s.leaks {
+		c := *l
+		byKey[key] = &c
+	}
+	for _, open := range s.openLeaks {
+		key := leakKey(open.kind, open.pkg, "")
+		l, ok := byKey[key]
+		if !ok {
+			c := *open
+			c.count = 0
+			l = &c
+			byKey[key] = l
+		}
+		l.count++
+	}
+	var out []*leak
+	for _, l := range byKey {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].pkg != out[j].pkg {
+			return out[i].pkg < out[j].pkg
+		}
+		if out[i].test != out[j].test {
+			return out[i].test < out[j].test
+		}
+		return out[i].kind < out[j].kind
+	})
+	return out
+}
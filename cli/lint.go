@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// Kinds of issues reported by the `lint` statistic.
+const (
+	// lintDuplicate is a test reporting a second result without running
+	// again, which would otherwise silently overwrite the first.
+	lintDuplicate = "duplicate-result"
+	// lintShadowed is a subtest whose name was already taken by a sibling,
+	// which Go disambiguates with a `#01` suffix.
+	lintShadowed = "shadowed-name"
+	// lintOutputAfterPass is a test printing output after it passed, as
+	// goroutines outliving their test do.
+	lintOutputAfterPass = "output-after-pass"
+	// lintNoResult is a test that started but never reported a result.
+	// Benchmarks are exempt, as test2json reports none for them.
+	lintNoResult = "no-result"
+)
+
+var lintKinds = []string{lintDuplicate, lintShadowed, lintOutputAfterPass, lintNoResult}
+
+// shadowedPattern matches the suffix Go appends to repeated subtest names.
+// Fuzz seed inputs are named alike, as `seed#0`, but have no unsuffixed
+// sibling.
+var shadowedPattern = regexp.MustCompile(`/[^/]*(#\d+)$`)
+
+type lintIssue struct {
+	kind  string
+	pkg   pkgid
+	test  string
+	count int
+}
+
+// lintState follows a test between its events.
+type lintState struct {
+	pkg     pkgid
+	test    string
+	running bool
+	// result is the terminal action reported since the test last ran.
+	result string
+}
+
+// recordLint checks each test's events against the order Go emits them in.
+func (s *stats) recordLint(line testjson.RawLine) {
+	if line.Test == "" {
+		return
+	}
+	key := testjson.TestID(line.Package, line.Test)
+	st, ok := s.lintStates[key]
+	if !ok {
+		st = &lintState{pkg: line.Package, test: line.Test}
+		s.lintStates[key] = st
+	}
+	switch line.Action {
+	case "run":
+		st.running, st.result = true, ""
+		if m := shadowedPattern.FindStringSubmatchIndex(line.Test); m != nil {
+			if _, ok := s.lintStates[testjson.TestID(line.Package, line.Test[:m[2]])]; ok {
+				s.addLintIssue(lintShadowed, st)
+			}
+		}
+	case "output":
+		if st.result == "pass" {
+			s.addLintIssue(lintOutputAfterPass, st)
+		}
+	case "pass", "fail", "skip":
+		if st.result != "" {
+			s.addLintIssue(lintDuplicate, st)
+		}
+		st.running, st.result = false, line.Action
+	}
+}
+
+func (s *stats) addLintIssue(kind string, st *lintState) {
+	key := kind + "\n" + testjson.TestID(st.pkg, st.test)
+	issue, ok := s.lintIssues[key]
+	if !ok {
+		issue = &lintIssue{kind: kind, pkg: st.pkg, test: st.test}
+		s.lintIssues[key] = issue
+	}
+	issue.count++
+}
+
+// lintIssuesByKind groups the issues found by kind, in the order of
+// lintKinds, each sorted by package and test. Tests still running at the
+// end of the input are reported as having no result.
+func (s *stats) lintIssuesByKind() map[string][]*lintIssue {
+	out := make(map[string][]*lintIssue)
+	for _, issue := range s.lintIssues {
+		out[issue.kind] = append(out[issue.kind], issue)
+	}
+	for _, st := range s.lintStates {
+		if st.running && !strings.HasPrefix(st.test, "Benchmark") {
+			out[lintNoResult] = append(out[lintNoResult], &lintIssue{kind: lintNoResult, pkg: st.pkg, test: st.test, count: 1})
+		}
+	}
+	for _, issues := range out {
+		sort.Slice(issues, func(i, j int) bool {
+			return testjson.TestID(issues[i].pkg, issues[i].test) < testjson.TestID(issues[j].pkg, issues[j].test)
+		})
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// logFormats lists the values accepted by the `-log-format` flag.
+var logFormats = []string{"text", "json"}
+
+// logFormat is a flag accepting one of logFormats.
+type logFormat string
+
+func (f *logFormat) String() string {
+	return string(*f)
+}
+
+func (f *logFormat) Set(v string) error {
+	for _, name := range logFormats {
+		if v == name {
+			*f = logFormat(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(logFormats, "|"))
+}
+
+// logger writes leveled messages with key-value fields to stderr. By default
+// it logs warnings and errors; -v adds informational and debugging messages
+// such as per-file timings, and -q leaves only errors.
+var logger = struct {
+	sync.Mutex
+	verbose bool
+	quiet   bool
+	format  logFormat
+}{format: "text"}
+
+// addLogFlags registers the logging flags, which every subcommand accepts.
+func addLogFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&logger.verbose, "v", false, "Log progress and timings of reading and analyzing inputs")
+	fs.BoolVar(&logger.quiet, "q", false, "Only log errors")
+	fs.Var(&logger.format, "log-format", "Log `format`: "+strings.Join(logFormats, "|"))
+}
+
+func logDebug(msg string, kv ...interface{}) { logAt(levelDebug, msg, kv) }
+func logInfo(msg string, kv ...interface{})  { logAt(levelInfo, msg, kv) }
+func logWarn(msg string, kv ...interface{})  { logAt(levelWarn, msg, kv) }
+
+// fatal logs its arguments, formatted like fmt.Sprint, as an error and exits
+// with code 1.
+func fatal(v ...interface{}) {
+	logAt(levelError, fmt.Sprint(v...), nil)
+	os.Exit(1)
+}
+
+func fatalf(format string, v ...interface{}) {
+	logAt(levelError, fmt.Sprintf(format, v...), nil)
+	os.Exit(1)
+}
+
+// logAt writes msg and the alternating keys and values in kv, as a line of
+// text in the style of the log package or as a JSON object.
+func logAt(level logLevel, msg string, kv []interface{}) {
+	min := levelWarn
+	switch {
+	case logger.quiet:
+		min = levelError
+	case logger.verbose:
+		min = levelDebug
+	}
+	if level < min {
+		return
+	}
+	now := time.Now()
+	var buf bytes.Buffer
+	if logger.format == "json" {
+		buf.WriteString(`{"time":`)
+		writeJSONValue(&buf, now.Format(time.RFC3339Nano))
+		buf.WriteString(`,"level":`)
+		writeJSONValue(&buf, strings.ToLower(levelNames[level]))
+		buf.WriteString(`,"msg":`)
+		writeJSONValue(&buf, msg)
+		for i := 0; i+1 < len(kv); i += 2 {
+			buf.WriteByte(',')
+			writeJSONValue(&buf, fmt.Sprint(kv[i]))
+			buf.WriteByte(':')
+			writeJSONValue(&buf, logValue(kv[i+1]))
+		}
+		buf.WriteString("}\n")
+	} else {
+		fmt.Fprintf(&buf, "%s %s %s", now.Format("2006/01/02 15:04:05"), levelNames[level], msg)
+		for i := 0; i+1 < len(kv); i += 2 {
+			v := fmt.Sprint(logValue(kv[i+1]))
+			if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+				v = strconv.Quote(v)
+			}
+			fmt.Fprintf(&buf, " %v=%s", kv[i], v)
+		}
+		buf.WriteByte('\n')
+	}
+	logger.Lock()
+	defer logger.Unlock()
+	os.Stderr.Write(buf.Bytes())
+}
+
+// logValue converts values without a useful JSON encoding to strings.
+func logValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(b)
+}
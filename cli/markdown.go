@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// markdownReport summarizes a run for posting to code review and chat tools:
+// failures, the top slowest tests and, given a base run, the regressions
+// beyond -regression-floor and -fail-on-regression.
+func markdownReport(s *stats, base *stats, top int, opts options) string {
+	var b strings.Builder
+	tests := s.TestsByDuration()
+
+	var failed []*testjson.Test
+	var total time.Duration
+	for _, t := range tests {
+		if !t.Passed {
+			failed = append(failed, t)
+		}
+		total += t.Duration
+	}
+	fmt.Fprintf(&b, "**%d tests** in %d packages, **%d failed**, %s cumulative test time.\n\n", len(tests), len(s.Packages), len(failed), opts.dur(total))
+
+	if builds := s.BuildFailuresByPackage(); len(builds) > 0 {
+		fmt.Fprintf(&b, "### Build failures\n\n")
+		for _, bf := range builds {
+			fmt.Fprintf(&b, "- `%s` (%s)\n", bf.Package, bf.Kind)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(&b, "### Failures\n\n")
+		for _, t := range failed {
+			fmt.Fprintf(&b, "- `%s` in `%s`\n", t.Name, t.Package)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	if len(tests) > top {
+		tests = tests[:top]
+	}
+	if len(tests) > 0 {
+		fmt.Fprintf(&b, "### Slowest tests\n\n| Test | Package | Duration |\n| --- | --- | ---: |\n")
+		for _, t := range tests {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s |\n", t.Name, t.Package, opts.dur(t.Duration))
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	if base != nil {
+		regressions := testRegressions(base, s, opts)
+		if len(regressions) > top {
+			regressions = regressions[:top]
+		}
+		fmt.Fprintf(&b, "### Regressions vs base\n\n")
+		if len(regressions) == 0 {
+			fmt.Fprintf(&b, "No test got slower.\n")
+		} else {
+			fmt.Fprintf(&b, "| Test | Package | Base | Head | Change |\n| --- | --- | ---: | ---: | ---: |\n")
+			for _, d := range regressions {
+				fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s (%+.0f%%) |\n", d.name, d.pkg, opts.dur(d.base), opts.dur(d.head), signed(opts.dur(d.change())), 100*d.ratio())
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+type matrixRow struct {
+	pkg  string
+	test string
+	// cells holds the latest result of the test in each run.
+	cells map[string]*testjson.Result
+}
+
+// runMatrix lays out the test results with one row per test and one column
+// per run, in the order the runs were read.
+func (s *stats) runMatrix() ([]string, []*matrixRow) {
+	var runs []string
+	seen := make(map[string]bool)
+	rows := make(map[id]*matrixRow)
+	for _, r := range s.Results {
+		if r.Kind != "test" {
+			continue
+		}
+		if !seen[r.Run] {
+			seen[r.Run] = true
+			runs = append(runs, r.Run)
+		}
+		key := testjson.TestID(r.Package, r.Test)
+		row, ok := rows[key]
+		if !ok {
+			row = &matrixRow{pkg: r.Package, test: r.Test, cells: make(map[string]*testjson.Result)}
+			rows[key] = row
+		}
+		row.cells[r.Run] = r
+	}
+	var out []*matrixRow
+	for _, row := range rows {
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return testjson.TestID(out[i].pkg, out[i].test) < testjson.TestID(out[j].pkg, out[j].test)
+	})
+	return runs, out
+}
+
+// printMatrix writes a header row naming the runs followed by the duration
+// and status of every test in each of them, `-` where it did not run.
+func printMatrix(w io.Writer, s *stats, opts options) {
+	runs, rows := s.runMatrix()
+	fmt.Fprintf(w, "test\tpkg\t%s\n", strings.Join(runs, "\t"))
+	for _, row := range rows {
+		cells := []string{row.test, row.pkg}
+		for _, run := range runs {
+			r, ok := row.cells[run]
+			if !ok {
+				cells = append(cells, "-")
+				continue
+			}
+			d := time.Duration(r.Duration * float64(time.Second))
+			cell := opts.dur(d) + " " + r.Status
+			if r.Status == "fail" {
+				cell = opts.paint(colorRed, cell)
+			}
+			cells = append(cells, cell)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+func merge(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	out := fs.String("o", "", "Write the merged stream to this `file` instead of stdout")
+	dedup := fs.Bool("dedup", false, "Drop events identical to one already written, e.g. from overlapping artifacts")
+	var read readOptions
+	fs.BoolVar(&read.strict, "strict", false, "Fail on input lines that are not JSON test events, and on files of unknown format, instead of skipping them")
+	fs.BoolVar(&read.normalizeTime, "normalize-time", false, "Shift each file's timestamps to start at the same instant, for runs from machines with skewed clocks")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats merge [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Combines runs into a single go test -json stream sorted by time, with build output first.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files, err := expandInputs(fs.Args())
+	if err != nil {
+		fatal(err)
+	}
+	var lines []testjson.RawLine
+	err = readFiles(files, read, func(path string, line testjson.RawLine) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		fatal(err)
+	}
+	// Stable, so that events sharing a timestamp and untimed build output
+	// keep their order.
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Time.Before(lines[j].Time) })
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		b, err := json.Marshal(line)
+		if err != nil {
+			fatal(err)
+		}
+		if *dedup {
+			if seen[string(b)] {
+				continue
+			}
+			seen[string(b)] = true
+		}
+		bw.Write(b)
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		fatal(err)
+	}
+}
@@ -0,0 +1,16 @@
+package cli
+
+import (
+	"sort"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+func (s *stats) noTestPackagesSorted() []*testjson.Package {
+	var out []*testjson.Package
+	for _, p := range s.NoTestPackages {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const defaultNotifyTemplate = `*go test*: {{.Tests}} tests in {{.Packages}} packages, {{.Failed}} failed, {{.Flaky}} flaky, {{dur .Duration}} total
+{{- if .BuildFailures}}
+
+*Build failures*
+{{- range .BuildFailures}}
+• {{.}}
+{{- end}}
+{{- end}}
+{{- if .Failures}}
+
+*Failures*
+{{- range .Failures}}
+• ` + "`{{.Name}}`" + ` ({{.Package}})
+{{- end}}
+{{- end}}
+{{- if .Flakes}}
+
+*Flaky*
+{{- range .Flakes}}
+• ` + "`{{.Name}}`" + ` ({{.Package}})
+{{- end}}
+{{- end}}
+{{- if .Slowest}}
+
+*Slowest*
+{{- range .Slowest}}
+• ` + "`{{.Name}}`" + ` {{dur .Duration}}
+{{- end}}
+{{- end}}
+`
+
+// postWebhook sends text in the `{"text": ...}` payload understood by Slack
+// incoming webhooks and compatible services.
+func postWebhook(url, text string) error {
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func notify(args []string) {
+	fs := flag.NewFlagSet("notify", flag.ExitOnError)
+	webhook := fs.String("webhook", os.Getenv("GOTESTSTATS_WEBHOOK_URL"), "Slack-compatible webhook `url` (default $GOTESTSTATS_WEBHOOK_URL)")
+	templateFile := fs.String("template", "", "Go text/template `file` for the message body, executed with the run summary, with durations formatted by `dur`")
+	var opts options
+	fs.StringVar(&opts.durationFormat, "duration-format", "human", "Duration `format` of dur in the message: "+strings.Join(durationFormats, "|"))
+	top := fs.Int("top", 5, "Number of slowest tests to include")
+	dryRun := fs.Bool("dry-run", false, "Print the message instead of posting it")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats notify [flags] [file1.json ... fileN.json]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	switch {
+	case *webhook == "" && !*dryRun:
+		fatal("notify: -webhook is required")
+	case !isDurationFormat(opts.durationFormat):
+		fatalf("notify: -duration-format must be one of `%s`", strings.Join(durationFormats, "`, `"))
+	}
+	text := defaultNotifyTemplate
+	if *templateFile != "" {
+		b, err := os.ReadFile(*templateFile)
+		if err != nil {
+			fatal(err)
+		}
+		text = string(b)
+	}
+	tmpl, err := template.New("notify").Funcs(template.FuncMap{"dur": opts.dur}).Parse(text)
+	if err != nil {
+		fatal(err)
+	}
+
+	s := newStatsFromFiles(fs.Args(), nil)
+	var msg bytes.Buffer
+	if err := tmpl.Execute(&msg, s.Summary(*top)); err != nil {
+		fatal(err)
+	}
+	if *dryRun {
+		fmt.Print(msg.String())
+		return
+	}
+	if err := postWebhook(*webhook, msg.String()); err != nil {
+		fatal(err)
+	}
+}
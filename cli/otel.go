@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+const defaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+// OTLP/HTTP JSON encoding of the subset of the trace data model we emit. See
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string        `json:"key"`
+	Value otlpAttrValue `json:"value"`
+}
+
+type otlpAttrValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+func otlpAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAttrValue{StringValue: value}}
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpStatusOf(passed bool) otlpStatus {
+	if passed {
+		return otlpStatus{Code: otlpStatusOK}
+	}
+	return otlpStatus{Code: otlpStatusError}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// parentTest returns the name of the test a subtest belongs to, or "" for a
+// top-level test.
+func parentTest(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// traceOf converts a run into a single trace: a root span covering the run
+// with a child span per package, and per test beneath its package or the test
+// it is a subtest of.
+func (s *stats) traceOf(service string) otlpTraces {
+	traceID := randomHex(16)
+	rootID := randomHex(8)
+
+	var spans []otlpSpan
+	var runStart, runEnd time.Time
+	passed := true
+
+	pkgSpans := make(map[pkgid]string)
+	for _, p := range s.PackagesByDuration() {
+		spanID := randomHex(8)
+		pkgSpans[p.ID] = spanID
+		spans = append(spans, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            spanID,
+			ParentSpanID:      rootID,
+			Name:              p.ID,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: otlpTime(p.Start),
+			EndTimeUnixNano:   otlpTime(p.End),
+			Attributes:        []otlpAttribute{otlpAttr("go.test.package", p.ID)},
+			Status:            otlpStatusOf(p.Passed),
+		})
+		if runStart.IsZero() || p.Start.Before(runStart) {
+			runStart = p.Start
+		}
+		if p.End.After(runEnd) {
+			runEnd = p.End
+		}
+		passed = passed && p.Passed
+	}
+
+	// Sorting by name places every test before its subtests.
+	tests := s.TestsByDuration()
+	sort.SliceStable(tests, func(i, j int) bool { return tests[i].Name < tests[j].Name })
+	testSpans := make(map[id]string)
+	for _, t := range tests {
+		spanID := randomHex(8)
+		testSpans[testjson.TestID(t.Package, t.Name)] = spanID
+		parent, ok := testSpans[testjson.TestID(t.Package, parentTest(t.Name))]
+		if !ok {
+			parent, ok = pkgSpans[t.Package]
+		}
+		if !ok {
+			parent = rootID
+		}
+		spans = append(spans, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            spanID,
+			ParentSpanID:      parent,
+			Name:              t.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: otlpTime(t.Start),
+			EndTimeUnixNano:   otlpTime(t.End),
+			Attributes: []otlpAttribute{
+				otlpAttr("go.test.package", t.Package),
+				otlpAttr("go.test.name", t.Name),
+			},
+			Status: otlpStatusOf(t.Passed),
+		})
+	}
+
+	root := otlpSpan{
+		TraceID:           traceID,
+		SpanID:            rootID,
+		Name:              "go test",
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: otlpTime(runStart),
+		EndTimeUnixNano:   otlpTime(runEnd),
+		Status:            otlpStatusOf(passed),
+	}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{otlpAttr("service.name", service)}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "goteststats"},
+			Spans: append([]otlpSpan{root}, spans...),
+		}},
+	}}}
+}
+
+// otlpHeaders parses the `key=value,key2=value2` syntax of
+// OTEL_EXPORTER_OTLP_HEADERS.
+func otlpHeaders(spec string) map[string]string {
+	headers := make(map[string]string)
+	for _, kv := range strings.Split(spec, ",") {
+		if i := strings.Index(kv, "="); i > 0 {
+			headers[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+		}
+	}
+	return headers
+}
+
+func defaultEndpoint() string {
+	if e := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); e != "" {
+		return e
+	}
+	if e := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); e != "" {
+		return strings.TrimSuffix(e, "/") + "/v1/traces"
+	}
+	return defaultOTLPEndpoint
+}
+
+func sendOTLP(endpoint string, headers map[string]string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("otlp: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func otelExport(args []string) {
+	fs := flag.NewFlagSet("otel-export", flag.ExitOnError)
+	endpoint := fs.String("endpoint", defaultEndpoint(), "OTLP/HTTP traces endpoint `url`")
+	service := fs.String("service-name", "go-test", "Value of the service.name resource attribute")
+	dryRun := fs.Bool("dry-run", false, "Print the OTLP JSON payload instead of sending it")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats otel-export [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Exports a run as OpenTelemetry spans over OTLP/HTTP. Headers are read from OTEL_EXPORTER_OTLP_HEADERS.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	s := newStatsFromFiles(fs.Args(), nil)
+	traces := s.traceOf(*service)
+	payload, err := json.Marshal(traces)
+	if err != nil {
+		fatal(err)
+	}
+	if *dryRun {
+		os.Stdout.Write(payload)
+		fmt.Println()
+		return
+	}
+	if err := sendOTLP(*endpoint, otlpHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")), payload); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Exported %d spans to %s\n", len(traces.ResourceSpans[0].ScopeSpans[0].Spans), *endpoint)
+}
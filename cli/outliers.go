@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// outlierMethods lists the values accepted by the `-outlier-method` flag.
+var outlierMethods = []string{"stddev", "mad"}
+
+// outlierMinHistory is the number of earlier samples a test needs before its
+// latest duration is judged against them.
+const outlierMinHistory = 3
+
+// madScale makes the median absolute deviation comparable to the standard
+// deviation of normally distributed samples.
+const madScale = 1.4826
+
+type outlier struct {
+	pkg    string
+	test   string
+	latest time.Duration
+	// center is the mean or median of the earlier samples, and score the
+	// number of standard deviations, or scaled MADs, the latest is off by.
+	center  time.Duration
+	score   float64
+	samples int
+}
+
+// outliers returns the tests whose latest duration, from the last input file
+// to run them, is more than k standard deviations, or with method `mad` k
+// scaled median absolute deviations, away from their earlier durations,
+// furthest first.
+func (s *stats) outliers(method string, k float64) []outlier {
+	history := make(map[id][]*testjson.Result)
+	var keys []id
+	for _, r := range s.Results {
+		if r.Kind != "test" || r.Status == "skip" {
+			continue
+		}
+		key := testjson.TestID(r.Package, r.Test)
+		if _, ok := history[key]; !ok {
+			keys = append(keys, key)
+		}
+		history[key] = append(history[key], r)
+	}
+	var out []outlier
+	for _, key := range keys {
+		rs := history[key]
+		if len(rs) <= outlierMinHistory {
+			continue
+		}
+		// Results are in the order of the input files, so the last is the
+		// latest run even where -normalize-time has made end times equal.
+		var earlier []float64
+		for _, r := range rs[:len(rs)-1] {
+			earlier = append(earlier, r.Duration)
+		}
+		latest := rs[len(rs)-1]
+		var center, scale float64
+		switch method {
+		case "mad":
+			center = median(earlier)
+			var deviations []float64
+			for _, d := range earlier {
+				deviations = append(deviations, math.Abs(d-center))
+			}
+			scale = madScale * median(deviations)
+		default:
+			center = mean(earlier)
+			scale = stddev(earlier)
+		}
+		if scale == 0 {
+			continue
+		}
+		score := (latest.Duration - center) / scale
+		if math.Abs(score) <= k {
+			continue
+		}
+		out = append(out, outlier{
+			pkg:     latest.Package,
+			test:    latest.Test,
+			latest:  fromSeconds(latest.Duration),
+			center:  fromSeconds(center),
+			score:   score,
+			samples: len(rs),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return math.Abs(out[j].score) < math.Abs(out[i].score) })
+	return out
+}
+
+func median(xs []float64) float64 {
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func stddev(xs []float64) float64 {
+	m := mean(xs)
+	var sum float64
+	for _, x := range xs {
+		sum += (x - m) * (x - m)
+	}
+	return math.Sqrt(sum / float64(len(xs)-1))
+}
+
+func fromSeconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+type outputSize struct {
+	pkg   pkgid
+	name  string
+	bytes int
+	lines int
+}
+
+func (s *stats) recordOutputSize(line testjson.RawLine) {
+	if line.Action != "output" {
+		return
+	}
+	add := func(sizes map[string]*outputSize, key, name string) {
+		o, ok := sizes[key]
+		if !ok {
+			o = &outputSize{pkg: line.Package, name: name}
+			sizes[key] = o
+		}
+		o.bytes += len(line.Output)
+		o.lines += strings.Count(line.Output, "\n")
+	}
+	add(s.pkgOutput, line.Package, "")
+	if line.Test != "" {
+		add(s.testOutput, testjson.TestID(line.Package, line.Test), line.Test)
+	}
+}
+
+func outputSizesSortedByBytesDescending(sizes map[string]*outputSize) []*outputSize {
+	var out []*outputSize
+	for _, o := range sizes {
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].bytes != out[j].bytes {
+			return out[j].bytes < out[i].bytes
+		}
+		return testjson.TestID(out[i].pkg, out[i].name) < testjson.TestID(out[j].pkg, out[j].name)
+	})
+	return out
+}
@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"sort"
+	"time"
+)
+
+// overhead is the package time not spent in its top-level tests: TestMain,
+// package initialization, setup and teardown. Parallel tests can add up to
+// more than the package's elapsed time, in which case there is no measurable
+// overhead and it is reported as zero.
+func (c *pkgCount) overhead() time.Duration {
+	if c.total >= c.duration {
+		return 0
+	}
+	return c.duration - c.total
+}
+
+func (s *stats) pkgCountsSortedByOverheadDescending() []*pkgCount {
+	out := s.pkgCountsSortedByTestsDescending()
+	sort.SliceStable(out, func(i, j int) bool { return out[j].overhead() < out[i].overhead() })
+	return out
+}
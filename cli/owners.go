@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unowned is reported for packages no ownership rule covers.
+const unowned = "(unowned)"
+
+// codeownersPaths are the locations GitHub reads CODEOWNERS from, relative
+// to the repository root.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+type ownerRule struct {
+	pattern string
+	owners  []string
+}
+
+// ownership maps packages to owners, either with CODEOWNERS rules matched
+// against package directories relative to the module root, or with a simple
+// mapping of `import/path/prefix team...` lines where the longest prefix wins.
+type ownership struct {
+	rules      []ownerRule
+	codeowners bool
+	module     string
+}
+
+// findCodeowners walks up from dir to the repository root and returns the
+// CODEOWNERS file found there, or an empty path if there is none.
+func findCodeowners(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			for _, p := range codeownersPaths {
+				if _, err := os.Stat(filepath.Join(dir, p)); err == nil {
+					return filepath.Join(dir, p)
+				}
+			}
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// modulePath reads the module path from the go.mod file in dir, if any.
+func modulePath(dir string) string {
+	b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, l := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(l)
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// readOwners reads a file named CODEOWNERS as CODEOWNERS rules, resolving
+// package directories against the go.mod of the repository root, and any
+// other file as a prefix mapping.
+func readOwners(p string) (*ownership, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	o := &ownership{codeowners: filepath.Base(p) == "CODEOWNERS"}
+	if o.codeowners {
+		root := filepath.Dir(p)
+		if b := filepath.Base(root); b == ".github" || b == "docs" {
+			root = filepath.Dir(root)
+		}
+		o.module = modulePath(root)
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		o.rules = append(o.rules, ownerRule{pattern: fields[0], owners: fields[1:]})
+	}
+	return o, scanner.Err()
+}
+
+func (o *ownership) ownersOf(p pkgid) []string {
+	var owners []string
+	if o.codeowners {
+		dir := p
+		if o.module != "" {
+			if dir != o.module && !strings.HasPrefix(dir, o.module+"/") {
+				return []string{unowned}
+			}
+			dir = strings.TrimPrefix(strings.TrimPrefix(dir, o.module), "/")
+		}
+		// As in CODEOWNERS, the last matching rule wins.
+		for _, r := range o.rules {
+			if codeownersMatch(r.pattern, dir) {
+				owners = r.owners
+			}
+		}
+	} else {
+		longest := -1
+		for _, r := range o.rules {
+			prefix := strings.TrimSuffix(r.pattern, "/")
+			if (p == prefix || strings.HasPrefix(p, prefix+"/")) && len(prefix) > longest {
+				owners, longest = r.owners, len(prefix)
+			}
+		}
+	}
+	if len(owners) == 0 {
+		return []string{unowned}
+	}
+	return owners
+}
+
+// codeownersMatch reports whether a CODEOWNERS pattern covers the package in
+// dir, by matching dir or any of its parent directories. As in gitignore, a
+// pattern containing a slash other than a trailing one is anchored to the
+// root; other patterns match a directory name at any depth. A `**` segment
+// matches any number of directories.
+func codeownersMatch(pattern, dir string) bool {
+	p := strings.TrimSuffix(strings.TrimSuffix(pattern, "/**"), "/")
+	if p == "*" || p == "" || p == "**" {
+		return true
+	}
+	if dir == "" {
+		return false
+	}
+	segs := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		segs = []string{"**", p}
+	}
+	parts := strings.Split(dir, "/")
+	for i := 1; i <= len(parts); i++ {
+		if matchSegments(segs, parts[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+type ownerStats struct {
+	owner    string
+	duration time.Duration
+	tests    int
+	failures int
+	flakes   int
+}
+
+// ownerStatsSortedByDurationDescending attributes top-level tests to the
+// owners of their package; a test with several owners counts for each.
+func (s *stats) ownerStatsSortedByDurationDescending(o *ownership) []*ownerStats {
+	byOwner := make(map[string]*ownerStats)
+	for _, t := range s.Tests {
+		if parentTest(t.Name) != "" {
+			continue
+		}
+		for _, owner := range o.ownersOf(t.Package) {
+			st, ok := byOwner[owner]
+			if !ok {
+				st = &ownerStats{owner: owner}
+				byOwner[owner] = st
+			}
+			st.duration += t.Duration
+			st.tests++
+			if !t.Passed {
+				st.failures++
+			}
+			if t.Flaky() {
+				st.flakes++
+			}
+		}
+	}
+	var out []*ownerStats
+	for _, st := range byOwner {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].duration != out[j].duration {
+			return out[j].duration < out[i].duration
+		}
+		return out[i].owner < out[j].owner
+	})
+	return out
+}
+
+// ownersFile returns the -owners file, or the repository's CODEOWNERS.
+func ownersFile(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if p := findCodeowners(wd); p != "" {
+		return p, nil
+	}
+	return "", fmt.Errorf("the owners statistic needs a CODEOWNERS file in the repository or an -owners file")
+}
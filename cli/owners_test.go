@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodeownersMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		dir     string
+		want    bool
+	}{
+		{"*", "a/b", true},
+		{"*", "", true},
+		{"/**", "a", true},
+
+		// Unanchored patterns match a directory name at any depth.
+		{"internal", "internal", true},
+		{"internal", "a/internal", true},
+		{"internal", "a/internal/b", true},
+		{"internal", "internals", false},
+		{"internal", "", false},
+		{"intern*", "a/internal", true},
+
+		// A trailing slash does not anchor a pattern.
+		{"internal/", "a/internal/b", true},
+		{"internal/", "internal", true},
+		{"internal/", "a/external", false},
+
+		// A leading or inner slash anchors it to the root.
+		{"/internal", "internal/b", true},
+		{"/internal", "a/internal", false},
+		{"/internal/", "a/internal", false},
+		{"a/internal", "a/internal/b", true},
+		{"a/internal", "b/a/internal", false},
+		{"/a/*/c", "a/b/c", true},
+		{"/a/*/c", "a/b/b/c", false},
+
+		// `**` spans any number of directories.
+		{"a/**", "a", true},
+		{"a/**", "a/b/c", true},
+		{"a/**", "b/a", false},
+		{"**/logs", "logs", true},
+		{"**/logs", "a/b/logs", true},
+		{"a/**/c", "a/c", true},
+		{"a/**/c", "a/b/b/c/d", true},
+		{"a/**/c", "b/c", false},
+	}
+	for _, tt := range tests {
+		if got := codeownersMatch(tt.pattern, tt.dir); got != tt.want {
+			t.Errorf("codeownersMatch(%q, %q) = %v, want %v", tt.pattern, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestOwnersOf(t *testing.T) {
+	codeowners := &ownership{
+		codeowners: true,
+		module:     "example.com/m",
+		rules: []ownerRule{
+			{"*", []string{"@all"}},
+			{"/internal/", []string{"@core"}},
+			{"internal/db", []string{"@db"}},
+			{"cmd", []string{"@cli", "@release"}},
+			{"/internal/db/migrate", []string{"@core"}},
+		},
+	}
+	prefixes := &ownership{
+		rules: []ownerRule{
+			{"example.com/m", []string{"@all"}},
+			{"example.com/m/internal/", []string{"@core"}},
+			{"example.com/m/internal/db", []string{"@db"}},
+		},
+	}
+	tests := []struct {
+		o    *ownership
+		pkg  pkgid
+		want []string
+	}{
+		{codeowners, "example.com/m", []string{"@all"}},
+		{codeowners, "example.com/m/api", []string{"@all"}},
+		// The last matching rule wins, however specific earlier ones are.
+		{codeowners, "example.com/m/internal/cache", []string{"@core"}},
+		{codeowners, "example.com/m/internal/db", []string{"@db"}},
+		{codeowners, "example.com/m/internal/db/migrate", []string{"@core"}},
+		{codeowners, "example.com/m/tools/cmd/gen", []string{"@cli", "@release"}},
+		{codeowners, "example.com/other", []string{unowned}},
+		{codeowners, "example.com/mm", []string{unowned}},
+
+		// Prefix mappings pick the longest matching prefix instead.
+		{prefixes, "example.com/m/api", []string{"@all"}},
+		{prefixes, "example.com/m/internal/db/migrate", []string{"@db"}},
+		{prefixes, "example.com/m/internal/dbx", []string{"@core"}},
+		{prefixes, "example.com/other", []string{unowned}},
+	}
+	for _, tt := range tests {
+		if got := tt.o.ownersOf(tt.pkg); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ownersOf(%q) = %v, want %v", tt.pkg, got, tt.want)
+		}
+	}
+}
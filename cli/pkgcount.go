@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"sort"
+	"time"
+)
+
+type pkgCount struct {
+	id       pkgid
+	tests    int
+	subtests int
+	total    time.Duration
+	duration time.Duration
+}
+
+// average is the mean duration of the package's top-level tests.
+func (c *pkgCount) average() time.Duration {
+	if c.tests == 0 {
+		return 0
+	}
+	return c.total / time.Duration(c.tests)
+}
+
+// pkgCountsSortedByTestsDescending counts the top-level tests and subtests of
+// every package. Subtests run within their parent, so only top-level tests
+// contribute to the total and average durations.
+func (s *stats) pkgCountsSortedByTestsDescending() []*pkgCount {
+	counts := make(map[pkgid]*pkgCount)
+	get := func(id pkgid) *pkgCount {
+		c, ok := counts[id]
+		if !ok {
+			c = &pkgCount{id: id}
+			counts[id] = c
+		}
+		return c
+	}
+	for _, p := range s.Packages {
+		get(p.ID).duration = p.Duration
+	}
+	for _, t := range s.Tests {
+		c := get(t.Package)
+		if parentTest(t.Name) != "" {
+			c.subtests++
+			continue
+		}
+		c.tests++
+		c.total += t.Duration
+	}
+	var out []*pkgCount
+	for _, c := range counts {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].tests != out[j].tests {
+			return out[j].tests < out[i].tests
+		}
+		if out[i].duration != out[j].duration {
+			return out[j].duration < out[i].duration
+		}
+		return out[i].id < out[j].id
+	})
+	return out
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// pluginPrefix names the executables on PATH providing statistics beyond the
+// built-in ones, as kubectl and git plugins do: `-statistic my-stat` runs
+// `goteststats-my-stat`.
+const pluginPrefix = "goteststats-"
+
+func pluginPath(statistic string) (string, bool) {
+	if statistic == "" {
+		return "", false
+	}
+	path, err := exec.LookPath(pluginPrefix + statistic)
+	return path, err == nil
+}
+
+func isPlugin(statistic string) bool {
+	_, ok := pluginPath(statistic)
+	return ok
+}
+
+// runPlugin runs the plugin for the statistic, streaming it every result as
+// written by `-format jsonl` on its standard input. The plugin writes its
+// report to w.
+func runPlugin(w io.Writer, statistic string, s *stats) error {
+	path, ok := pluginPath(statistic)
+	if !ok {
+		return fmt.Errorf("unknown statistic %q", statistic)
+	}
+	pr, pw := io.Pipe()
+	// Closing the reader unblocks the writer when the plugin stops reading
+	// early.
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(writeJSONL(pw, s))
+	}()
+	cmd := exec.Command(path)
+	cmd.Stdin = pr
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	logDebug("running plugin", "statistic", statistic, "path", path)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("plugin %s: %v", path, err)
+	}
+	return nil
+}
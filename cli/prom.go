@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const promJob = "goteststats"
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type promSample struct {
+	labels string
+	value  float64
+}
+
+// promLabels renders alternating label names and values.
+func promLabels(kv ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(kv); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, kv[i], promLabelEscaper.Replace(kv[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func writePromMetric(w io.Writer, name, help string, samples []promSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].labels < samples[j].labels })
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name); err != nil {
+		return err
+	}
+	for _, x := range samples {
+		if _, err := fmt.Fprintf(w, "%s%s %g\n", name, x.labels, x.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePrometheus writes the run in the Prometheus text exposition format.
+func writePrometheus(w io.Writer, s *stats) error {
+	var pkgDurations, testDurations, counts, flakes []promSample
+	for _, p := range s.Packages {
+		pkgDurations = append(pkgDurations, promSample{promLabels("package", p.ID), p.Duration.Seconds()})
+	}
+
+	type key struct {
+		pkg    pkgid
+		status string
+	}
+	byStatus := make(map[key]int)
+	byPkgFlakes := make(map[pkgid]int)
+	for _, t := range s.Tests {
+		testDurations = append(testDurations, promSample{promLabels("package", t.Package, "test", t.Name), t.Duration.Seconds()})
+		byStatus[key{t.Package, "pass"}] += t.Passes
+		byStatus[key{t.Package, "fail"}] += t.Failures
+		if t.Flaky() {
+			byPkgFlakes[t.Package]++
+		}
+	}
+	for k, n := range byStatus {
+		counts = append(counts, promSample{promLabels("package", k.pkg, "status", k.status), float64(n)})
+	}
+	for p, n := range byPkgFlakes {
+		flakes = append(flakes, promSample{promLabels("package", p), float64(n)})
+	}
+
+	metrics := []struct {
+		name, help string
+		samples    []promSample
+	}{
+		{"goteststats_package_duration_seconds", "Elapsed time of the latest run of each package.", pkgDurations},
+		{"goteststats_test_duration_seconds", "Elapsed time of the latest run of each test.", testDurations},
+		{"goteststats_test_results", "Number of test results by package and status.", counts},
+		{"goteststats_flaky_tests", "Number of tests that both passed and failed, by package.", flakes},
+	}
+	for _, m := range metrics {
+		if err := writePromMetric(w, m.name, m.help, m.samples); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushPrometheus replaces the metrics of the goteststats job on a Pushgateway.
+// A url without a `/metrics/job/` path is pushed under the default job name.
+func pushPrometheus(url string, s *stats) error {
+	var buf bytes.Buffer
+	if err := writePrometheus(&buf, s); err != nil {
+		return err
+	}
+	if !strings.Contains(url, "/metrics/job/") {
+		url = strings.TrimSuffix(url, "/") + "/metrics/job/" + promJob
+	}
+	req, err := http.NewRequest(http.MethodPut, url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("pushgateway: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
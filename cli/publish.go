@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// publishers are the targets of the `publish` subcommand.
+var publishers = map[string]func(args []string){
+	"azure":  publishAzure,
+	"github": publishGitHub,
+	"gitlab": publishGitLab,
+}
+
+func publish(args []string) {
+	var names []string
+	for name := range publishers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(args) == 0 || publishers[args[0]] == nil {
+		fmt.Fprintf(os.Stderr, "Usage: goteststats publish <%s> [flags] [file1.json ... fileN.json]\n", strings.Join(names, "|"))
+		os.Exit(2)
+	}
+	publishers[args[0]](args[1:])
+}
+
+// reportCase is a test result as CI test reports present it, with a message
+// and details for failures and the reason for skips.
+type reportCase struct {
+	pkg      string
+	name     string
+	status   string
+	duration float64
+	start    time.Time
+	end      time.Time
+	message  string
+	details  string
+}
+
+// reportCases lists every test result in the order they finished, and the
+// packages that failed without a failed test, such as on build failures,
+// under the name of the package. It needs the stats to capture output.
+func reportCases(s *stats) []reportCase {
+	failedTests := make(map[string]bool)
+	for _, r := range s.Results {
+		if r.Kind == "test" && r.Status == "fail" {
+			failedTests[r.Package] = true
+		}
+	}
+	var out []reportCase
+	for _, r := range s.Results {
+		if r.Kind != "test" && (r.Status != "fail" || failedTests[r.Package]) {
+			continue
+		}
+		c := reportCase{pkg: r.Package, name: r.Test, status: r.Status, duration: r.Duration, start: r.Start, end: r.End}
+		switch {
+		case r.Kind == "package":
+			c.name = r.Package
+			c.message = "package failed"
+			if b := s.BuildFailures[r.Package]; b != nil {
+				c.message = b.Kind + " failed"
+				c.details = strings.Join(b.Output, "\n")
+			}
+		case r.Status == "fail":
+			output := s.Outputs[testjson.TestID(r.Package, r.Test)]
+			c.message = failureMessage(output)
+			c.details = strings.Join(output, "\n")
+		case r.Status == "skip":
+			c.message = s.skipReasons[testjson.TestID(r.Package, r.Test)]
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// failureMessage picks the line of test output most likely to explain a
+// failure: the first panic or t.Error style `file_test.go:12: ...` line.
+func failureMessage(output []string) string {
+	for _, l := range output {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "panic: ") || strings.Contains(l, "_test.go:") {
+			return l
+		}
+	}
+	return "test failed"
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+	"gopkg.in/yaml.v3"
+)
+
+// quarantineEntry describes a test that flaked often enough to quarantine.
+type quarantineEntry struct {
+	Schema    string    `json:"schema" yaml:"schema"`
+	Package   string    `json:"package" yaml:"package"`
+	Test      string    `json:"test" yaml:"test"`
+	FlakeRate float64   `json:"flake_rate" yaml:"flake_rate"`
+	Runs      int       `json:"runs" yaml:"runs"`
+	Failures  int       `json:"failures" yaml:"failures"`
+	FirstSeen time.Time `json:"first_seen" yaml:"first_seen"`
+	LastSeen  time.Time `json:"last_seen" yaml:"last_seen"`
+}
+
+// quarantineList returns the tests that both passed and failed over the
+// ingested results and failed in at least minRate of their runs, highest rate
+// first. First and last seen are the times of their earliest and latest
+// failures.
+func quarantineList(s *stats, minRate float64) []quarantineEntry {
+	byTest := make(map[id]*quarantineEntry)
+	passed := make(map[id]bool)
+	var keys []id
+	for _, r := range s.Results {
+		if r.Kind != "test" || r.Status == "skip" {
+			continue
+		}
+		key := testjson.TestID(r.Package, r.Test)
+		e, ok := byTest[key]
+		if !ok {
+			e = &quarantineEntry{Schema: quarantineSchema, Package: r.Package, Test: r.Test}
+			byTest[key] = e
+			keys = append(keys, key)
+		}
+		e.Runs++
+		if r.Status == "pass" {
+			passed[key] = true
+			continue
+		}
+		e.Failures++
+		if e.FirstSeen.IsZero() || r.End.Before(e.FirstSeen) {
+			e.FirstSeen = r.End
+		}
+		if r.End.After(e.LastSeen) {
+			e.LastSeen = r.End
+		}
+	}
+	var out []quarantineEntry
+	for _, key := range keys {
+		e := byTest[key]
+		e.FlakeRate = float64(e.Failures) / float64(e.Runs)
+		if passed[key] && e.Failures > 0 && e.FlakeRate >= minRate {
+			out = append(out, *e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[j].FlakeRate < out[i].FlakeRate })
+	return out
+}
+
+func quarantine(args []string) {
+	fs := flag.NewFlagSet("quarantine", flag.ExitOnError)
+	minRate := percent(0.05)
+	fs.Var(&minRate, "min-rate", "Only list tests failing in at least this `percentage` of their runs")
+	format := fs.String("format", "json", "Output format: json|yaml")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats quarantine [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Lists flaky tests, which both passed and failed over the given runs, to quarantine.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *format != "json" && *format != "yaml" {
+		fatal("quarantine: -format must be one of `json`, `yaml`")
+	}
+
+	s := newStatsFromFiles(fs.Args(), nil)
+	list := quarantineList(s, float64(minRate))
+	if list == nil {
+		list = []quarantineEntry{}
+	}
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			fatal(err)
+		}
+	case "yaml":
+		if err := yaml.NewEncoder(os.Stdout).Encode(list); err != nil {
+			fatal(err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+const (
+	raceHeader    = "WARNING: DATA RACE"
+	raceSeparator = "=================="
+	// raceExcerptLines bounds the stack excerpt kept for each race.
+	raceExcerptLines = 12
+)
+
+type race struct {
+	pkg     pkgid
+	test    string
+	count   int
+	excerpt []string
+	// signature is the frames of the stacks, which tell races of the same
+	// test apart.
+	signature string
+}
+
+// raceBlock is a race report being read from a package's output.
+type raceBlock struct {
+	test   string
+	frames []string
+	lines  []string
+}
+
+// recordRace collects `WARNING: DATA RACE` reports, attributing each to the
+// test it was printed by or, failing that, the test running most recently.
+func (s *stats) recordRace(line testjson.RawLine) {
+	if line.Action != "output" {
+		return
+	}
+	out := strings.TrimRight(line.Output, "\n")
+	block := s.raceBlocks[line.Package]
+	switch {
+	case out == raceHeader:
+		test := line.Test
+		if running := s.running[line.Package]; test == "" && len(running) > 0 {
+			test = running[len(running)-1]
+		}
+		s.raceBlocks[line.Package] = &raceBlock{test: test}
+	case block == nil:
+	case out == raceSeparator:
+		delete(s.raceBlocks, line.Package)
+		s.addRace(line.Package, block)
+	default:
+		if len(block.lines) < raceExcerptLines {
+			block.lines = append(block.lines, out)
+		}
+		// Frames are printed as `  pkg.Func()` followed by an indented
+		// `file:line +0xoffset` line.
+		if strings.HasPrefix(out, "  ") && !strings.HasPrefix(out, "   ") {
+			block.frames = append(block.frames, strings.TrimSpace(out))
+		}
+	}
+}
+
+func (s *stats) addRace(pkg pkgid, b *raceBlock) {
+	signature := strings.Join(b.frames, "\n")
+	key := testjson.TestID(pkg, b.test) + "\n" + signature
+	r, ok := s.races[key]
+	if !ok {
+		r = &race{pkg: pkg, test: b.test, excerpt: b.lines, signature: signature}
+		s.races[key] = r
+	}
+	r.count++
+}
+
+func (s *stats) racesSortedByCountDescending() []*race {
+	var out []*race
+	for _, r := range s.races {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		if ti, tj := testjson.TestID(out[i].pkg, out[i].test), testjson.TestID(out[j].pkg, out[j].test); ti != tj {
+			return ti < tj
+		}
+		return out[i].signature < out[j].signature
+	})
+	return out
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// testKinds are the name prefixes that `go test` gives meaning to. Redacted
+// test names keep them so that tests, benchmarks, fuzz targets and examples
+// can still be told apart.
+var testKinds = []string{"Test", "Benchmark", "Fuzz", "Example"}
+
+// redactor replaces package paths and test names with opaque identifiers,
+// so that reports can be shared without revealing the structure of a
+// codebase. Identifiers are an HMAC of the name under a key drawn for each
+// run: the same name maps to the same identifier within a run, but guessed
+// names cannot be hashed to match them. The -redact-map file is the only way
+// back.
+type redactor struct {
+	key []byte
+	// originals maps each identifier handed out back to its name.
+	originals map[string]string
+}
+
+func newRedactor() (*redactor, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &redactor{key: key, originals: make(map[string]string)}, nil
+}
+
+func (r *redactor) id(prefix, name string) string {
+	if name == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(name))
+	id := prefix + hex.EncodeToString(mac.Sum(nil))[:10]
+	r.originals[id] = name
+	return id
+}
+
+func (r *redactor) pkg(p string) string {
+	return r.id("pkg-", p)
+}
+
+// test redacts each level of a subtest name separately, so that subtests
+// still nest under their redacted parent.
+func (r *redactor) test(name string) string {
+	if name == "" {
+		return ""
+	}
+	parts := strings.Split(name, "/")
+	out := make([]string, len(parts))
+	for i := range parts {
+		prefix := ""
+		if i == 0 {
+			for _, k := range testKinds {
+				if strings.HasPrefix(parts[0], k) {
+					prefix = k
+				}
+			}
+		}
+		out[i] = r.id(prefix+"_", strings.Join(parts[:i+1], "/"))
+	}
+	return strings.Join(out, "/")
+}
+
+// line redacts the names in an event. Output keeps its text, with the
+// event's own package path and test name replaced; it may still mention
+// other identifiers, which is why the statistics in redactRefused are not
+// allowed with -redact.
+func (r *redactor) line(l testjson.RawLine) testjson.RawLine {
+	pkg := l.Package
+	if pkg == "" && l.ImportPath != "" {
+		pkg = strings.Fields(l.ImportPath)[0]
+	}
+	if l.Output != "" {
+		var pairs []string
+		if pkg != "" {
+			pairs = append(pairs, pkg, r.pkg(pkg))
+		}
+		if l.Test != "" {
+			pairs = append(pairs, l.Test, r.test(l.Test))
+		}
+		l.Output = strings.NewReplacer(pairs...).Replace(l.Output)
+	}
+	l.Package = r.pkg(l.Package)
+	l.Test = r.test(l.Test)
+	l.ImportPath = r.id("build-", l.ImportPath)
+	l.FailedBuild = r.id("build-", l.FailedBuild)
+	return l
+}
+
+// writeMapping saves the identifiers handed out as `identifier<TAB>name`
+// lines, for translating a shared report back.
+func (r *redactor) writeMapping(path string) error {
+	var ids []string
+	for id := range r.originals {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var b strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&b, "%s\t%s\n", id, r.originals[id])
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// redactRefused lists the statistics that print excerpts of test or build
+// output, which redaction cannot clean.
+var redactRefused = map[string]bool{
+	"crashes":        true,
+	"races":          true,
+	"build-failures": true,
+	"skip-reasons":   true,
+	"leaks":          true,
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// runUnsupported holds the statistics and formats that need flags run does
+// not have, with the flags to use them with on the saved run instead.
+var runUnsupported = map[string]string{
+	"coverage":     "-coverprofile",
+	"diff":         "-baseline",
+	"budget":       "budgets in -config",
+	"outliers":     "-outlier-method and -outlier-k",
+	"compare-by":   "-compare-by",
+	"heatmap":      "-heatmap-by",
+	"near-timeout": "-timeout and -near-timeout",
+	"template":     "-template-file",
+	"heatmap-html": "-heatmap-by",
+}
+
+// runTests runs `go test -json` with the arguments after `--`, saves its
+// output and reports on the run once it completes, exiting with the status
+// of go test.
+func runTests(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	out := fs.String("o", "test.json", "Save the raw go test -json output to this `file` (empty to not save it)")
+	var opts options
+	fs.StringVar(&opts.statistic, "statistic", "test-time", "Statistic to print when the run completes: "+strings.Join(statisticNames(), "|"))
+	fs.StringVar(&opts.format, "format", "text", "Output format: "+strings.Join(formats, "|"))
+	fs.BoolVar(&opts.summary, "summary", true, "Append run totals to text output")
+	fs.StringVar(&opts.colorMode, "color", "auto", "Color text output by status: "+strings.Join(colorModes, "|"))
+	fs.BoolVar(&opts.failOnTestFailure, "fail-on-test-failure", true, "Exit with go test's exit code when it fails, instead of only when it cannot run the tests")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats run [flags] [-- go test flags and packages]\n\n")
+		fmt.Fprintf(fs.Output(), "Runs go test -json (on ./... unless packages are given) and reports on the run.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	switch {
+	case !isStatistic(opts.statistic) && !isPlugin(opts.statistic):
+		fatalf("run: -statistic must be one of `%s`", strings.Join(statisticNames(), "`, `"))
+	case runUnsupported[opts.statistic] != "":
+		fatalf("run: the %s statistic is not supported, save the run and report on it with %s", opts.statistic, runUnsupported[opts.statistic])
+	case !isFormat(opts.format):
+		fatalf("run: -format must be one of `%s`", strings.Join(formats, "`, `"))
+	case runUnsupported[opts.format] != "":
+		fatalf("run: the %s format is not supported, save the run and report on it with %s", opts.format, runUnsupported[opts.format])
+	case !isColorMode(opts.colorMode):
+		fatalf("run: -color must be one of `%s`", strings.Join(colorModes, "`, `"))
+	}
+	opts.durationFormat = "go"
+	opts.color = useColor(opts.colorMode)
+
+	goArgs := append([]string{"test", "-json"}, fs.Args()...)
+	if fs.NArg() == 0 {
+		goArgs = append(goArgs, "./...")
+	}
+	cmd := exec.Command("go", goArgs...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		fatal(err)
+	}
+	var in io.Reader = stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		in = io.TeeReader(stdout, f)
+	}
+	if err := cmd.Start(); err != nil {
+		fatal(err)
+	}
+
+	s := newStats()
+	s.Tags.Run = *out
+	if opts.format == "compact" {
+		s.OnResult = newCompactWriter(os.Stdout, opts).add
+	}
+	if _, err := testjson.ReadEvents(in, false, s.add); err != nil {
+		fatal(err)
+	}
+	waitErr := cmd.Wait()
+	var exitErr *exec.ExitError
+	if waitErr != nil && !errors.As(waitErr, &exitErr) {
+		fatal(waitErr)
+	}
+
+	if opts.format == "compact" {
+		// Package lines were printed as the run went.
+		if opts.summary {
+			printSummary(os.Stdout, s, opts)
+		}
+	} else if err := render(os.Stdout, s, opts); err != nil {
+		fatal(err)
+	}
+	// go test exits with 1 when tests or builds fail, and with 2 when it
+	// could not run them, such as for bad flags.
+	if exitErr != nil && (opts.failOnTestFailure || exitErr.ExitCode() != 1) {
+		os.Exit(exitErr.ExitCode())
+	}
+}
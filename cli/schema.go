@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// Versions of the structured outputs besides testjson.ResultSchema. Like
+// it, each changes whenever a field is removed or changes meaning.
+const (
+	quarantineSchema = "goteststats/quarantine/v1"
+	packageSchema    = "goteststats/api/package/v1"
+	testSchema       = "goteststats/api/test/v1"
+	runSchema        = "goteststats/api/run/v1"
+)
+
+// outputSchema describes a structured output for `goteststats schema`.
+type outputSchema struct {
+	name        string
+	id          string
+	description string
+	// record is a value of the type of the records in the output, which
+	// is a JSON array of them when array is set.
+	record interface{}
+	array  bool
+	// enums lists the values of fields limited to a fixed set.
+	enums map[string][]string
+}
+
+var outputSchemas = []outputSchema{
+	{
+		name:        "result",
+		id:          testjson.ResultSchema,
+		description: "A package or test result, one per line of -format jsonl output.",
+		record:      testjson.Result{},
+		enums: map[string][]string{
+			"kind":   {"package", "test"},
+			"status": {"pass", "fail", "skip", testjson.StatusNoTests},
+		},
+	},
+	{
+		name:        "quarantine",
+		id:          quarantineSchema,
+		description: "The flaky tests listed by the quarantine subcommand, as JSON or YAML.",
+		record:      quarantineEntry{},
+		array:       true,
+	},
+	{
+		name:        "api-packages",
+		id:          packageSchema,
+		description: "The packages served by the serve subcommand at /api/packages.",
+		record:      packageJSON{},
+		array:       true,
+	},
+	{
+		name:        "api-tests",
+		id:          testSchema,
+		description: "The tests served by the serve subcommand at /api/tests.",
+		record:      testJSON{},
+		array:       true,
+	},
+	{
+		name:        "api-runs",
+		id:          runSchema,
+		description: "The runs served by the serve subcommand at /api/runs.",
+		record:      runJSON{},
+		array:       true,
+	},
+}
+
+// jsonSchema returns the JSON Schema of the output, derived from the json
+// tags of its record type. The schema field is pinned to the version.
+func (o outputSchema) jsonSchema() map[string]interface{} {
+	record := jsonSchemaOf(reflect.TypeOf(o.record))
+	props := record["properties"].(map[string]interface{})
+	props["schema"] = map[string]interface{}{"const": o.id}
+	for field, values := range o.enums {
+		props[field].(map[string]interface{})["enum"] = values
+	}
+	doc := record
+	if o.array {
+		doc = map[string]interface{}{"type": "array", "items": record}
+	}
+	doc["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	doc["$id"] = o.id
+	doc["description"] = o.description
+	return doc
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func jsonSchemaOf(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": jsonSchemaOf(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		props := make(map[string]interface{})
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if f.PkgPath != "" || tag == "-" {
+				continue
+			}
+			name, opts := tag, ""
+			if i := strings.Index(tag, ","); i >= 0 {
+				name, opts = tag[:i], tag[i:]
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = jsonSchemaOf(f.Type)
+			if !strings.Contains(opts, ",omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{"type": "object", "properties": props, "required": required}
+	}
+	return map[string]interface{}{}
+}
+
+func schema(args []string) {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats schema [output]\n\n")
+		fmt.Fprintf(fs.Output(), "Prints the JSON Schema of a structured output, or lists the outputs and their versions.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		for _, o := range outputSchemas {
+			fmt.Printf("%s\t%s\t%s\n", o.name, o.id, o.description)
+		}
+		return
+	}
+	for _, o := range outputSchemas {
+		if o.name == fs.Arg(0) {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(o.jsonSchema()); err != nil {
+				fatal(err)
+			}
+			return
+		}
+	}
+	fatalf("schema: unknown output %q, see goteststats schema", fs.Arg(0))
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+type packageJSON struct {
+	Schema   string  `json:"schema"`
+	Package  string  `json:"package"`
+	Duration float64 `json:"duration_seconds"`
+	Passed   bool    `json:"passed"`
+}
+
+type testJSON struct {
+	Schema   string  `json:"schema"`
+	Package  string  `json:"package"`
+	Test     string  `json:"test"`
+	Duration float64 `json:"duration_seconds"`
+	Passed   bool    `json:"passed"`
+	Flaky    bool    `json:"flaky"`
+}
+
+type runJSON struct {
+	Schema   string    `json:"schema"`
+	File     string    `json:"file"`
+	Start    time.Time `json:"start"`
+	Packages int       `json:"packages"`
+	Tests    int       `json:"tests"`
+	Failed   int       `json:"failed"`
+	Duration float64   `json:"duration_seconds"`
+}
+
+// server serves the statistics of a set of files, re-reading them whenever
+// the files or the contents of the watched directory change.
+type server struct {
+	dir   string
+	files []string
+
+	mu          sync.Mutex
+	fingerprint string
+	stats       *stats
+	runs        []runJSON
+}
+
+func (srv *server) inputs() ([]string, string, error) {
+	args := srv.files
+	if srv.dir != "" {
+		args = append(append([]string(nil), args...), srv.dir)
+	}
+	files, err := expandInputs(args)
+	if err != nil {
+		return nil, "", err
+	}
+	var fp strings.Builder
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, "", err
+		}
+		fmt.Fprintf(&fp, "%s:%d:%d;", f, info.Size(), info.ModTime().UnixNano())
+	}
+	return files, fp.String(), nil
+}
+
+func summarizeRun(file string, s *stats) runJSON {
+	r := runJSON{Schema: runSchema, File: file, Packages: len(s.Packages), Tests: len(s.Tests)}
+	for _, p := range s.Packages {
+		if r.Start.IsZero() || p.Start.Before(r.Start) {
+			r.Start = p.Start
+		}
+		r.Duration += p.Duration.Seconds()
+	}
+	for _, t := range s.Tests {
+		if !t.Passed {
+			r.Failed++
+		}
+	}
+	return r
+}
+
+func (srv *server) load() (*stats, []runJSON, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	files, fp, err := srv.inputs()
+	if err != nil {
+		return nil, nil, err
+	}
+	if srv.stats != nil && fp == srv.fingerprint {
+		return srv.stats, srv.runs, nil
+	}
+	s := newStats()
+	var runs []runJSON
+	perFile := make(map[string]*stats)
+	for _, f := range files {
+		perFile[f] = newStats()
+	}
+	err = readFiles(files, readOptions{}, func(f string, line testjson.RawLine) {
+		perFile[f].add(line)
+		s.add(line)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range files {
+		runs = append(runs, summarizeRun(f, perFile[f]))
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Start.Before(runs[j].Start) })
+	srv.stats, srv.runs, srv.fingerprint = s, runs, fp
+	return s, runs, nil
+}
+
+func (srv *server) handle(fn func(s *stats, runs []runJSON) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, runs, err := srv.load()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(fn(s, runs)); err != nil {
+			logWarn("writing response failed", "path", r.URL.Path, "err", err)
+		}
+	}
+}
+
+func (srv *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboardHTML)
+	})
+	mux.HandleFunc("/api/packages", srv.handle(func(s *stats, _ []runJSON) interface{} {
+		out := []packageJSON{}
+		for _, p := range s.PackagesByDuration() {
+			out = append(out, packageJSON{Schema: packageSchema, Package: p.ID, Duration: p.Duration.Seconds(), Passed: p.Passed})
+		}
+		return out
+	}))
+	mux.HandleFunc("/api/tests", srv.handle(func(s *stats, _ []runJSON) interface{} {
+		out := []testJSON{}
+		for _, t := range s.TestsByDuration() {
+			out = append(out, testJSON{Schema: testSchema, Package: t.Package, Test: t.Name, Duration: t.Duration.Seconds(), Passed: t.Passed, Flaky: t.Flaky()})
+		}
+		return out
+	}))
+	mux.HandleFunc("/api/runs", srv.handle(func(_ *stats, runs []runJSON) interface{} {
+		if runs == nil {
+			return []runJSON{}
+		}
+		return runs
+	}))
+	return mux
+}
+
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+	dir := fs.String("dir", "", "Directory searched recursively for run files to serve, re-read as files are added")
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats serve [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Serves a dashboard and the JSON endpoints /api/packages, /api/tests and /api/runs.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	srv := &server{dir: *dir, files: fs.Args()}
+	if _, _, err := srv.load(); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Serving on http://%s/\n", *addr)
+	fatal(http.ListenAndServe(*addr, srv.routes()))
+}
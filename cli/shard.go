@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+type shardItem struct {
+	name     string
+	duration time.Duration
+}
+
+type shard struct {
+	items []shardItem
+	total time.Duration
+}
+
+// averageDurations averages package (or test) durations over the runs in
+// files, treating every file as one run.
+func averageDurations(args []string, byTest bool) []shardItem {
+	files, err := expandInputs(args)
+	if err != nil {
+		fatal(err)
+	}
+	sums := make(map[string]time.Duration)
+	counts := make(map[string]int)
+	for _, f := range files {
+		s := newStatsFromFiles([]string{f}, nil)
+		if byTest {
+			for _, t := range s.Tests {
+				// Subtests run within their parent and cannot be sharded.
+				if parentTest(t.Name) != "" {
+					continue
+				}
+				k := testjson.TestID(t.Package, t.Name)
+				sums[k] += t.Duration
+				counts[k]++
+			}
+		} else {
+			for _, p := range s.Packages {
+				sums[p.ID] += p.Duration
+				counts[p.ID]++
+			}
+		}
+	}
+	var out []shardItem
+	for k, sum := range sums {
+		out = append(out, shardItem{name: k, duration: sum / time.Duration(counts[k])})
+	}
+	return out
+}
+
+// planShards assigns items to n shards with the longest-processing-time-first
+// heuristic: the slowest remaining item goes to the least loaded shard.
+func planShards(items []shardItem, n int) []shard {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].duration != items[j].duration {
+			return items[i].duration > items[j].duration
+		}
+		return items[i].name < items[j].name
+	})
+	shards := make([]shard, n)
+	for _, it := range items {
+		least := 0
+		for i := range shards {
+			if shards[i].total < shards[least].total {
+				least = i
+			}
+		}
+		shards[least].items = append(shards[least].items, it)
+		shards[least].total += it.duration
+	}
+	return shards
+}
+
+func shardPlan(args []string) {
+	fs := flag.NewFlagSet("shard-plan", flag.ExitOnError)
+	n := fs.Int("shards", 0, "Number of shards to split into")
+	by := fs.String("by", "pkg", "Unit to distribute: pkg|test")
+	index := fs.Int("index", 0, "Only print the items of this shard (1-based), one per line")
+	var opts options
+	fs.StringVar(&opts.durationFormat, "duration-format", "go", "Duration `format` in the plan: "+strings.Join(durationFormats, "|"))
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats shard-plan -shards N [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Balances packages or tests across shards by their average duration over the given runs.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	switch {
+	case *n < 1:
+		fatal("shard-plan: -shards must be at least 1")
+	case *by != "pkg" && *by != "test":
+		fatal("shard-plan: -by must be one of `pkg`, `test`")
+	case *index < 0 || *index > *n:
+		fatalf("shard-plan: -index must be between 1 and %d", *n)
+	case !isDurationFormat(opts.durationFormat):
+		fatalf("shard-plan: -duration-format must be one of `%s`", strings.Join(durationFormats, "`, `"))
+	}
+
+	shards := planShards(averageDurations(fs.Args(), *by == "test"), *n)
+	if *index > 0 {
+		for _, it := range shards[*index-1].items {
+			fmt.Println(it.name)
+		}
+		return
+	}
+	for i, sh := range shards {
+		fmt.Printf("# shard %d: %d items, expected %s\n", i+1, len(sh.items), opts.dur(sh.total))
+		for _, it := range sh.items {
+			fmt.Printf("%s\t%s\n", it.name, opts.dur(it.duration))
+		}
+	}
+}
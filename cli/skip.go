@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// noSkipReason is reported for tests skipped without a message.
+const noSkipReason = "(no reason)"
+
+// logLinePattern matches the `file_test.go:21: ` prefix t.Log and t.Skip put
+// before messages.
+var logLinePattern = regexp.MustCompile(`^\s+\S+\.go:\d+: `)
+
+// recordSkip remembers the latest logged message of every running test,
+// which for a skipped test is the reason given to t.Skip: Go prints it just
+// before, or in older versions just after, the `--- SKIP` line.
+func (s *stats) recordSkip(line testjson.RawLine) {
+	if line.Test == "" {
+		return
+	}
+	key := testjson.TestID(line.Package, line.Test)
+	switch line.Action {
+	case "run":
+		delete(s.lastLog, key)
+	case "output":
+		if loc := logLinePattern.FindString(line.Output); loc != "" {
+			s.lastLog[key] = strings.TrimSpace(line.Output[len(loc):])
+		}
+	case "skip":
+		reason, ok := s.lastLog[key]
+		if !ok {
+			reason = noSkipReason
+		}
+		s.skipReasons[key] = reason
+		delete(s.lastLog, key)
+	case "pass", "fail":
+		delete(s.lastLog, key)
+	}
+}
+
+type skipReason struct {
+	reason string
+	tests  []*testjson.Test
+}
+
+// skipReasonsSortedByCountDescending groups skipped tests by their reason.
+func (s *stats) skipReasonsSortedByCountDescending() []*skipReason {
+	byReason := make(map[string]*skipReason)
+	for key, t := range s.Skips {
+		reason := s.skipReasons[key]
+		if reason == "" {
+			reason = noSkipReason
+		}
+		r, ok := byReason[reason]
+		if !ok {
+			r = &skipReason{reason: reason}
+			byReason[reason] = r
+		}
+		r.tests = append(r.tests, t)
+	}
+	var out []*skipReason
+	for _, r := range byReason {
+		sort.Slice(r.tests, func(i, j int) bool {
+			return testjson.TestID(r.tests[i].Package, r.tests[i].Name) < testjson.TestID(r.tests[j].Package, r.tests[j].Name)
+		})
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].tests) != len(out[j].tests) {
+			return len(out[j].tests) < len(out[i].tests)
+		}
+		return out[i].reason < out[j].reason
+	})
+	return out
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// Input formats, as detected from the content of each file.
+const (
+	formatGoTest  = "go-test-json"
+	formatJUnit   = "junit-xml"
+	formatUnknown = "unknown"
+)
+
+// sniffSize is how much of a file, after decompression, is looked at to
+// detect its format.
+const sniffSize = 64 << 10
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// sniffFormat detects the format of a file from its first bytes: JUnit XML
+// if it is XML with a testsuite element, go test JSON if any of its lines is
+// a test or build event, and unknown otherwise, as for stray logs.
+func sniffFormat(head []byte) string {
+	head = bytes.TrimPrefix(head, utf8BOM)
+	if bytes.HasPrefix(bytes.TrimSpace(head), []byte("<")) {
+		if bytes.Contains(head, []byte("<testsuite")) {
+			return formatJUnit
+		}
+		return formatUnknown
+	}
+	for _, line := range bytes.Split(head, []byte("\n")) {
+		var l testjson.RawLine
+		if json.Unmarshal(line, &l) == nil && (l.Action != "" || l.ImportPath != "") {
+			return formatGoTest
+		}
+	}
+	return formatUnknown
+}
+
+// sniffFile detects the format of the file at path.
+func sniffFile(path string) (string, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head, err := bufio.NewReaderSize(f, sniffSize).Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", err
+	}
+	return sniffFormat(head), nil
+}
+
+// fileSummary describes what was read from a file.
+type fileSummary struct {
+	format string
+	// skipped counts the lines of go test JSON that were not events.
+	skipped int
+}
+
+// readFile reads the events of a file of any supported format, detected by
+// content rather than name, so that directories of mixed artifacts can be
+// read as a whole. Files of unknown format named as go test JSON are read as
+// such; others yield no events, or an error when strict.
+func readFile(path string, strict bool, fn func(testjson.RawLine)) (fileSummary, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return fileSummary{}, err
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(f, sniffSize)
+	head, err := r.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return fileSummary{}, err
+	}
+	sum := fileSummary{format: sniffFormat(head)}
+	if sum.format == formatUnknown && hasExtension(path, jsonExtensions) {
+		sum.format = formatGoTest
+	}
+	switch sum.format {
+	case formatJUnit:
+		end := time.Now()
+		if info, err := os.Stat(path); err == nil {
+			end = info.ModTime()
+		}
+		return sum, readBazelXML(r, path, end, fn)
+	case formatGoTest:
+		sum.skipped, err = testjson.ReadEvents(r, strict, fn)
+		return sum, err
+	}
+	if strict {
+		return sum, fmt.Errorf("unknown format, neither go test JSON nor JUnit XML")
+	}
+	return sum, nil
+}
+
+// inputFile is a file read into the statistics, reported by the `inputs`
+// statistic.
+type inputFile struct {
+	path string
+	fileSummary
+	events int
+}
+
+func printInputs(w io.Writer, s *stats) {
+	for _, in := range s.inputs {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", in.path, in.format, in.events, in.skipped)
+	}
+}
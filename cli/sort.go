@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// sortFields lists the keys accepted by the `-sort` flag.
+var sortFields = []string{"duration", "name", "package", "status"}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// parseSort parses a comma-separated `-sort` list such as
+// `status,duration:desc`. Durations sort descending unless `:asc` is given;
+// the other fields sort ascending, which puts failures before passes.
+func parseSort(spec string) ([]sortKey, error) {
+	var keys []sortKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field, order := part, ""
+		if i := strings.Index(part, ":"); i >= 0 {
+			field, order = part[:i], part[i+1:]
+		}
+		k := sortKey{field: field, desc: field == "duration"}
+		switch order {
+		case "":
+		case "asc":
+			k.desc = false
+		case "desc":
+			k.desc = true
+		default:
+			return nil, fmt.Errorf("sort order %q must be asc or desc", order)
+		}
+		valid := false
+		for _, f := range sortFields {
+			valid = valid || f == field
+		}
+		if !valid {
+			return nil, fmt.Errorf("sort key %q must be one of %s", field, strings.Join(sortFields, ", "))
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func compareDurations(a, b time.Duration) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareStrings(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+// less builds an ordering from keys given a comparison of two elements by field.
+func less(keys []sortKey, compare func(field string, i, j int) int) func(i, j int) bool {
+	return func(i, j int) bool {
+		for _, k := range keys {
+			c := compare(k.field, i, j)
+			if k.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	}
+}
+
+func sortTests(tests []*testjson.Test, keys []sortKey) {
+	sort.SliceStable(tests, less(keys, func(field string, i, j int) int {
+		a, b := tests[i], tests[j]
+		switch field {
+		case "duration":
+			return compareDurations(a.Duration, b.Duration)
+		case "name":
+			return compareStrings(a.Name, b.Name)
+		case "package":
+			return compareStrings(a.Package, b.Package)
+		default:
+			return compareStrings(statusOf(a.Passed), statusOf(b.Passed))
+		}
+	}))
+}
+
+func sortPackages(pkgs []*testjson.Package, keys []sortKey) {
+	sort.SliceStable(pkgs, less(keys, func(field string, i, j int) int {
+		a, b := pkgs[i], pkgs[j]
+		switch field {
+		case "duration":
+			return compareDurations(a.Duration, b.Duration)
+		case "status":
+			return compareStrings(statusOf(a.Passed), statusOf(b.Passed))
+		default:
+			return compareStrings(a.ID, b.ID)
+		}
+	}))
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// checkRegisteredStatistics rejects statistics registered under the name of a
+// built-in one, which `-statistic` would never select.
+func checkRegisteredStatistics() {
+	for _, name := range testjson.StatisticNames() {
+		for _, builtin := range statistics {
+			if name == builtin {
+				panic(fmt.Sprintf("RegisterStatistic: statistic %q is built in", name))
+			}
+		}
+	}
+}
+
+// statisticNames lists the built-in statistics followed by the registered
+// ones.
+func statisticNames() []string {
+	return append(append([]string(nil), statistics...), testjson.StatisticNames()...)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// subtestGroup aggregates the direct subtests, or cases, of a test.
+type subtestGroup struct {
+	pkg     pkgid
+	parent  string
+	cases   int
+	total   time.Duration
+	slowest *testjson.Test
+}
+
+func (g *subtestGroup) average() time.Duration {
+	return g.total / time.Duration(g.cases)
+}
+
+// subtestGroupsSortedByTotalDescending groups subtests under their parent,
+// so that table-driven tests with many cases read as a single row each.
+// Nested subtests are grouped under their own parent.
+func (s *stats) subtestGroupsSortedByTotalDescending() []*subtestGroup {
+	groups := make(map[id]*subtestGroup)
+	for _, t := range s.Tests {
+		parent := parentTest(t.Name)
+		if parent == "" {
+			continue
+		}
+		key := testjson.TestID(t.Package, parent)
+		g, ok := groups[key]
+		if !ok {
+			g = &subtestGroup{pkg: t.Package, parent: parent}
+			groups[key] = g
+		}
+		g.cases++
+		g.total += t.Duration
+		if g.slowest == nil || t.Duration > g.slowest.Duration ||
+			t.Duration == g.slowest.Duration && t.Name < g.slowest.Name {
+			g.slowest = t
+		}
+	}
+	var out []*subtestGroup
+	for _, g := range groups {
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].total != out[j].total {
+			return out[j].total < out[i].total
+		}
+		return testjson.TestID(out[i].pkg, out[i].parent) < testjson.TestID(out[j].pkg, out[j].parent)
+	})
+	return out
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// printSummary writes the totals of a run as `summary` rows, separated from
+// any preceding statistic by a blank line.
+func printSummary(w io.Writer, s *stats, opts options) {
+	sum := s.Summary(1)
+	if opts.statistic != "" {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "summary\ttests\t%d\n", sum.Tests)
+	fmt.Fprintf(w, "summary\tpassed\t%d\n", sum.Passed)
+	fmt.Fprintf(w, "summary\tfailed\t%d\n", sum.Failed)
+	// A broken build reports no tests, so it would otherwise go unnoticed.
+	builds := fmt.Sprintf("summary\tbuild-failures\t%d", len(sum.BuildFailures))
+	if len(sum.BuildFailures) > 0 {
+		builds = opts.paint(colorRed, builds)
+	}
+	fmt.Fprintln(w, builds)
+	fmt.Fprintf(w, "summary\tskipped\t%d\n", sum.Skipped)
+	fmt.Fprintf(w, "summary\tpackages\t%d\n", sum.Packages)
+	fmt.Fprintf(w, "summary\tno-test-packages\t%d\n", sum.NoTestPackages)
+	if sum.PackageResults > 0 {
+		fmt.Fprintf(w, "summary\tcache-hits\t%d/%d\t%.1f%%\n", sum.Cached, sum.PackageResults, 100*float64(sum.Cached)/float64(sum.PackageResults))
+	}
+	fmt.Fprintf(w, "summary\tduration\t%s\n", opts.dur(sum.Duration))
+	for _, t := range sum.Slowest {
+		fmt.Fprintf(w, "summary\tslowest\t%s\t%s\t%s\n", t.Name, t.Package, opts.dur(t.Duration))
+	}
+}
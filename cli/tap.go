@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/t0yv0/goteststats/testjson"
+	"gopkg.in/yaml.v3"
+)
+
+// tapDiagnostics is the YAML diagnostics block following each test point.
+type tapDiagnostics struct {
+	Package    string  `yaml:"package"`
+	DurationMS float64 `yaml:"duration_ms"`
+	Attempt    int     `yaml:"attempt,omitempty"`
+	Output     string  `yaml:"output,omitempty"`
+}
+
+// writeTAP writes every test result as a TAP version 13 test point, with its
+// package and duration in a YAML diagnostics block, and the output of
+// failed tests. Packages that failed without a failed test, such as on build
+// failures, get a test point of their own.
+func writeTAP(w io.Writer, s *stats) error {
+	failedTests := make(map[string]bool)
+	for _, r := range s.Results {
+		if r.Kind == "test" && r.Status == "fail" {
+			failedTests[r.Package] = true
+		}
+	}
+	var points []*testjson.Result
+	for _, r := range s.Results {
+		if r.Kind == "test" || r.Status == "fail" && !failedTests[r.Package] {
+			points = append(points, r)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version 13\n1..%d\n", len(points))
+	for i, r := range points {
+		key := r.Package
+		description := r.Package
+		if r.Kind == "test" {
+			key = testjson.TestID(r.Package, r.Test)
+			description = r.Package + " " + r.Test
+		}
+		status := "ok"
+		if r.Status == "fail" {
+			status = "not ok"
+		}
+		fmt.Fprintf(&b, "%s %d - %s", status, i+1, tapEscape(description))
+		if r.Status == "skip" {
+			fmt.Fprintf(&b, " # SKIP %s", tapEscape(s.skipReasons[key]))
+		}
+		b.WriteString("\n")
+
+		d := tapDiagnostics{Package: r.Package, DurationMS: math.Round(r.Duration*1e6) / 1e3}
+		if r.Attempt > 1 {
+			d.Attempt = r.Attempt
+		}
+		if r.Status == "fail" && r.Kind == "test" {
+			d.Output = strings.Join(s.Outputs[key], "\n")
+		}
+		diag, err := yaml.Marshal(d)
+		if err != nil {
+			return err
+		}
+		b.WriteString("  ---\n")
+		for _, l := range strings.SplitAfter(strings.TrimSuffix(string(diag), "\n"), "\n") {
+			if l != "\n" {
+				b.WriteString("  ")
+			}
+			b.WriteString(l)
+		}
+		b.WriteString("\n  ...\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tapEscape escapes the characters with a meaning in test point lines.
+func tapEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "#", "\\#", "\n", " ").Replace(s)
+}
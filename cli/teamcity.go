@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// teamcityTimestamp is the time format of service message timestamps.
+const teamcityTimestamp = "2006-01-02T15:04:05.000-0700"
+
+var teamcityEscaper = strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]")
+
+// teamcityMessage formats a service message with attributes given as
+// alternating names and values.
+func teamcityMessage(name string, attrs ...string) string {
+	var b strings.Builder
+	b.WriteString("##teamcity[" + name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		fmt.Fprintf(&b, " %s='%s'", attrs[i], teamcityEscaper.Replace(attrs[i+1]))
+	}
+	b.WriteString("]\n")
+	return b.String()
+}
+
+// writeTeamCity replays the results as TeamCity service messages: a test
+// suite per package and run, and within it the start, output, outcome and
+// duration of each test. Packages that failed without a failed test, such as
+// on build failures, are reported as build problems.
+func writeTeamCity(w io.Writer, s *stats) error {
+	type suite struct {
+		pkg     string
+		results []*testjson.Result
+		failed  bool
+	}
+	var suites []*suite
+	byKey := make(map[string]*suite)
+	for _, r := range s.Results {
+		key := r.Run + "\n" + r.Package
+		st, ok := byKey[key]
+		if !ok {
+			st = &suite{pkg: r.Package}
+			byKey[key] = st
+			suites = append(suites, st)
+		}
+		if r.Kind == "test" {
+			st.results = append(st.results, r)
+			st.failed = st.failed || r.Status == "fail"
+		} else if r.Status == "fail" && !st.failed {
+			st.results = append(st.results, r)
+		}
+	}
+
+	var b strings.Builder
+	for _, st := range suites {
+		flow := st.pkg
+		b.WriteString(teamcityMessage("testSuiteStarted", "name", st.pkg, "flowId", flow))
+		for _, r := range st.results {
+			if r.Kind == "package" {
+				b.WriteString(teamcityMessage("buildProblem", "description", r.Package+" failed without failing tests", "identity", r.Package, "flowId", flow))
+				continue
+			}
+			key := testjson.TestID(r.Package, r.Test)
+			b.WriteString(teamcityMessage("testStarted", "name", r.Test, "timestamp", r.Start.Format(teamcityTimestamp), "flowId", flow))
+			// Failures carry their output as the details of testFailed.
+			if out := s.Outputs[key]; len(out) > 0 && r.Status != "fail" {
+				b.WriteString(teamcityMessage("testStdOut", "name", r.Test, "out", strings.Join(out, "\n"), "flowId", flow))
+			}
+			switch r.Status {
+			case "fail":
+				b.WriteString(teamcityMessage("testFailed", "name", r.Test, "message", r.Test+" failed", "details", strings.Join(s.Outputs[key], "\n"), "flowId", flow))
+			case "skip":
+				b.WriteString(teamcityMessage("testIgnored", "name", r.Test, "message", s.skipReasons[key], "flowId", flow))
+			}
+			ms := time.Duration(r.Duration * float64(time.Second)).Milliseconds()
+			b.WriteString(teamcityMessage("testFinished", "name", r.Test, "duration", fmt.Sprint(ms), "timestamp", r.End.Format(teamcityTimestamp), "flowId", flow))
+		}
+		b.WriteString(teamcityMessage("testSuiteFinished", "name", st.pkg, "flowId", flow))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// templatePackage and the types below are exported views of the model for
+// `-format template`, whose templates can only refer to exported fields.
+type templatePackage struct {
+	Package  string
+	Duration time.Duration
+	Passed   bool
+	Cached   bool
+	Start    time.Time
+	End      time.Time
+}
+
+type templateTest struct {
+	Package  string
+	Name     string
+	Parent   string
+	Duration time.Duration
+	Passed   bool
+	Flaky    bool
+	Passes   int
+	Failures int
+	Start    time.Time
+	End      time.Time
+}
+
+type templateSkip struct {
+	Package string
+	Name    string
+	Reason  string
+}
+
+type templateCrash struct {
+	Package string
+	Kind    string
+	Message string
+	Running []string
+}
+
+type templateBuildFailure struct {
+	Package string
+	Kind    string
+	Output  []string
+}
+
+// templateData is what `-format template` templates are executed with:
+// totals as in notify templates, plus every package, test, skipped test,
+// crash, build failure and individual result, slowest first where it
+// applies.
+type templateData struct {
+	Summary       testjson.Summary
+	Packages      []templatePackage
+	Tests         []templateTest
+	Skipped       []templateSkip
+	Crashes       []templateCrash
+	BuildFailures []templateBuildFailure
+	Results       []*testjson.Result
+}
+
+func newTemplateData(s *stats) templateData {
+	d := templateData{Summary: s.Summary(10), Results: s.Results}
+	for _, p := range s.PackagesByDuration() {
+		d.Packages = append(d.Packages, templatePackage{Package: p.ID, Duration: p.Duration, Passed: p.Passed, Cached: p.Cached, Start: p.Start, End: p.End})
+	}
+	for _, t := range s.TestsByDuration() {
+		d.Tests = append(d.Tests, templateTest{
+			Package: t.Package, Name: t.Name, Parent: parentTest(t.Name), Duration: t.Duration, Passed: t.Passed,
+			Flaky: t.Flaky(), Passes: t.Passes, Failures: t.Failures, Start: t.Start, End: t.End,
+		})
+	}
+	for key, t := range s.Skips {
+		d.Skipped = append(d.Skipped, templateSkip{Package: t.Package, Name: t.Name, Reason: s.skipReasons[key]})
+	}
+	sort.Slice(d.Skipped, func(i, j int) bool {
+		return testjson.TestID(d.Skipped[i].Package, d.Skipped[i].Name) < testjson.TestID(d.Skipped[j].Package, d.Skipped[j].Name)
+	})
+	for _, c := range s.crashes {
+		d.Crashes = append(d.Crashes, templateCrash{Package: c.pkg, Kind: c.kind, Message: c.message, Running: c.running})
+	}
+	for _, b := range s.BuildFailuresByPackage() {
+		d.BuildFailures = append(d.BuildFailures, templateBuildFailure{Package: b.Package, Kind: b.Kind, Output: b.Output})
+	}
+	return d
+}
+
+// writeTemplate renders the run through the user's -template-file. Besides
+// the standard functions templates can use `dur` to format durations per
+// -duration-format, `seconds`, and `join`.
+func writeTemplate(w io.Writer, s *stats, opts options) error {
+	b, err := os.ReadFile(opts.templateFile)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"dur":     opts.dur,
+		"seconds": func(d time.Duration) float64 { return d.Seconds() },
+		"join":    strings.Join,
+	}).Parse(string(b))
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, newTemplateData(s))
+}
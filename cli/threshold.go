@@ -0,0 +1,45 @@
+package cli
+
+import "fmt"
+
+// exitThresholdExceeded is the exit code used when a `-fail-if-*` gate trips,
+// distinct from 1 (fatal errors) and exitUsage.
+const exitThresholdExceeded = 3
+
+// exitUsage is the exit code for invalid flags and flag combinations, as
+// used by the flag package, so that a misconfigured gate does not pass.
+const exitUsage = 2
+
+func (o options) hasThresholds() bool {
+	return o.testOver > 0 || o.pkgOver > 0 || len(o.budgets) > 0 || o.failOnRegression > 0
+}
+
+// thresholdViolations lists the packages and tests exceeding the configured
+// duration thresholds, slowest first.
+func (s *stats) thresholdViolations(opts options) []string {
+	var out []string
+	if opts.pkgOver > 0 {
+		for _, p := range s.PackagesByDuration() {
+			if p.Duration <= opts.pkgOver {
+				break
+			}
+			out = append(out, fmt.Sprintf("pkg\t%s\t%s\t> %s", p.ID, opts.dur(p.Duration), opts.dur(opts.pkgOver)))
+		}
+	}
+	if len(opts.budgets) > 0 {
+		for _, p := range s.PackagesByDuration() {
+			if budget, ok := opts.budgets[p.ID]; ok && p.Duration > budget {
+				out = append(out, fmt.Sprintf("budget\t%s\t%s\t> %s", p.ID, opts.dur(p.Duration), opts.dur(budget)))
+			}
+		}
+	}
+	if opts.testOver > 0 {
+		for _, t := range s.TestsByDuration() {
+			if t.Duration <= opts.testOver {
+				break
+			}
+			out = append(out, fmt.Sprintf("test\t%s\t%s\t%s\t> %s", t.Name, t.Package, opts.dur(t.Duration), opts.dur(opts.testOver)))
+		}
+	}
+	return append(out, s.regressionViolations(opts)...)
+}
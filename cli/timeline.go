@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+const (
+	timelineWidth     = 1200
+	timelineLabel     = 320
+	timelineRowHeight = 14
+)
+
+// timelineRow is one lane of the chart: a package, or a test below it.
+type timelineRow struct {
+	label  string
+	start  time.Time
+	end    time.Time
+	passed bool
+	isPkg  bool
+}
+
+// writeTimeline renders a Gantt chart of packages and their tests over
+// wall-clock time, in package start order.
+func writeTimeline(w io.Writer, s *stats, opts options) error {
+	pkgs := s.PackagesByDuration()
+	sort.SliceStable(pkgs, func(i, j int) bool { return pkgs[i].Start.Before(pkgs[j].Start) })
+	testsByPkg := make(map[pkgid][]*testjson.Test)
+	for _, t := range s.TestsByDuration() {
+		testsByPkg[t.Package] = append(testsByPkg[t.Package], t)
+	}
+
+	var rows []timelineRow
+	var t0, t1 time.Time
+	for _, p := range pkgs {
+		rows = append(rows, timelineRow{label: p.ID, start: p.Start, end: p.End, passed: p.Passed, isPkg: true})
+		if t0.IsZero() || p.Start.Before(t0) {
+			t0 = p.Start
+		}
+		if p.End.After(t1) {
+			t1 = p.End
+		}
+		tests := testsByPkg[p.ID]
+		sort.SliceStable(tests, func(i, j int) bool { return tests[i].Start.Before(tests[j].Start) })
+		for _, t := range tests {
+			rows = append(rows, timelineRow{label: t.Name, start: t.Start, end: t.End, passed: t.Passed})
+		}
+	}
+	span := t1.Sub(t0)
+	if span <= 0 {
+		span = time.Millisecond
+	}
+	plot := float64(timelineWidth - timelineLabel - 10)
+	x := func(t time.Time) float64 {
+		return timelineLabel + plot*float64(t.Sub(t0))/float64(span)
+	}
+	height := (len(rows)+2)*timelineRowHeight + 10
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="10">`+"\n", timelineWidth, height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	for i := 0; i <= 10; i++ {
+		gx := timelineLabel + plot*float64(i)/10
+		fmt.Fprintf(w, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#eee"/>`+"\n", gx, timelineRowHeight, gx, height)
+		fmt.Fprintf(w, `<text x="%.1f" y="10" text-anchor="middle" fill="#666">%s</text>`+"\n", gx, opts.dur((span * time.Duration(i) / 10).Round(time.Millisecond)))
+	}
+	for i, r := range rows {
+		y := (i + 1) * timelineRowHeight
+		fill := "#4a7bd0"
+		if r.isPkg {
+			fill = "#23427a"
+		}
+		if !r.passed {
+			fill = "#d04a4a"
+		}
+		label := r.label
+		indent := 4
+		if !r.isPkg {
+			indent = 16
+		}
+		width := x(r.end) - x(r.start)
+		if width < 1 {
+			width = 1
+		}
+		fmt.Fprintf(w, `<text x="%d" y="%d">%s</text>`+"\n", indent, y+10, html.EscapeString(label))
+		fmt.Fprintf(w, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"><title>%s %s</title></rect>`+"\n",
+			x(r.start), y+2, width, timelineRowHeight-4, fill, html.EscapeString(label), opts.dur(r.end.Sub(r.start)))
+	}
+	_, err := fmt.Fprintf(w, "</svg>\n")
+	return err
+}
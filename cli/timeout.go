@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// defaultTestTimeout is the timeout go test applies without -timeout.
+const defaultTestTimeout = 10 * time.Minute
+
+// detectedTimeouts returns the timeout of each package that timed out, read
+// from its `panic: test timed out after 10m0s` message.
+func (s *stats) detectedTimeouts() map[pkgid]time.Duration {
+	out := make(map[pkgid]time.Duration)
+	for _, c := range s.crashes {
+		if c.kind != "timeout" {
+			continue
+		}
+		i := strings.LastIndex(c.message, " after ")
+		if i < 0 {
+			continue
+		}
+		if d, err := time.ParseDuration(strings.TrimSpace(c.message[i+len(" after "):])); err == nil {
+			out[c.pkg] = d
+		}
+	}
+	return out
+}
+
+type nearTimeout struct {
+	kind     string
+	name     string
+	pkg      pkgid
+	duration time.Duration
+	timeout  time.Duration
+}
+
+func (n *nearTimeout) fraction() float64 {
+	return float64(n.duration) / float64(n.timeout)
+}
+
+// nearTimeouts lists the packages and tests taking more than fraction of the
+// timeout of their test binary, the longest relative to it first. The
+// timeout is the one given, or else the one detected for the package, or
+// for any package of the run, or go test's default.
+func (s *stats) nearTimeouts(timeout time.Duration, fraction float64) []*nearTimeout {
+	detected := s.detectedTimeouts()
+	fallback := defaultTestTimeout
+	for _, d := range detected {
+		if fallback == defaultTestTimeout || d < fallback {
+			fallback = d
+		}
+	}
+	timeoutOf := func(p pkgid) time.Duration {
+		if timeout > 0 {
+			return timeout
+		}
+		if d, ok := detected[p]; ok {
+			return d
+		}
+		return fallback
+	}
+
+	var out []*nearTimeout
+	for _, p := range s.Packages {
+		n := &nearTimeout{kind: "pkg", name: p.ID, pkg: p.ID, duration: p.Duration, timeout: timeoutOf(p.ID)}
+		if n.fraction() > fraction {
+			out = append(out, n)
+		}
+	}
+	for _, t := range s.Tests {
+		n := &nearTimeout{kind: "test", name: t.Name, pkg: t.Package, duration: t.Duration, timeout: timeoutOf(t.Package)}
+		if n.fraction() > fraction {
+			out = append(out, n)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if fi, fj := out[i].fraction(), out[j].fraction(); fi != fj {
+			return fi > fj
+		}
+		if out[i].kind != out[j].kind {
+			return out[i].kind < out[j].kind
+		}
+		return testjson.TestID(out[i].pkg, out[i].name) < testjson.TestID(out[j].pkg, out[j].name)
+	})
+	return out
+}
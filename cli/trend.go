@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// dateFlag is a flag accepting an RFC 3339 timestamp or a plain date.
+type dateFlag struct {
+	time.Time
+}
+
+func (d *dateFlag) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(time.RFC3339)
+}
+
+func (d *dateFlag) Set(v string) error {
+	t, err := parseDate(v)
+	d.Time = t
+	return err
+}
+
+// trendPoint sums up the runs of one commit or, for runs without a commit,
+// one run.
+type trendPoint struct {
+	key    string
+	commit string
+	branch string
+	run    string
+	time   time.Time
+	// suite is the total duration of the packages tested.
+	suite    time.Duration
+	tests    int
+	failures int
+	// latest holds the latest result of each test.
+	latest map[id]*testjson.Result
+}
+
+// trendKey identifies the trend point of a result: its commit, or its run.
+func trendKey(r *testjson.Result) string {
+	if r.Commit != "" {
+		return "commit\n" + r.Commit
+	}
+	return "run\n" + r.Run
+}
+
+// trend orders the results by commit time, falling back to when they ran,
+// keeping the points within [since, until] where set.
+func (s *stats) trend(since, until time.Time) []*trendPoint {
+	points := make(map[string]*trendPoint)
+	var out []*trendPoint
+	for _, r := range s.Results {
+		key := trendKey(r)
+		p, ok := points[key]
+		if !ok {
+			p = &trendPoint{key: key, commit: r.Commit, branch: r.Branch, run: r.Run, time: r.Start, latest: make(map[id]*testjson.Result)}
+			if r.CommitTime != nil {
+				p.time = *r.CommitTime
+			}
+			points[key] = p
+			out = append(out, p)
+		}
+		if r.CommitTime == nil && r.Start.Before(p.time) {
+			p.time = r.Start
+		}
+		if r.Kind == "package" {
+			p.suite += fromSeconds(r.Duration)
+			continue
+		}
+		if r.Status == "skip" {
+			continue
+		}
+		p.tests++
+		if r.Status == "fail" {
+			p.failures++
+		}
+		p.latest[testjson.TestID(r.Package, r.Test)] = r
+	}
+	var kept []*trendPoint
+	for _, p := range out {
+		if (since.IsZero() || !p.time.Before(since)) && (until.IsZero() || !p.time.After(until)) {
+			kept = append(kept, p)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].time.Before(kept[j].time) })
+	return kept
+}
+
+// printTrend writes a row per commit with its time, suite duration, number of
+// tests and failures. With -test-filter, the durations of the matching tests
+// follow indented beneath.
+func printTrend(w io.Writer, s *stats, opts options) {
+	for _, p := range s.trend(opts.since.Time, opts.until.Time) {
+		name, branch := shortCommit(p.commit), p.branch
+		if name == "" {
+			name = p.run
+		}
+		if branch == "" {
+			branch = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n", name, branch, p.time.Format(time.RFC3339), opts.dur(p.suite), p.tests, p.failures)
+		if opts.testFilter == "" {
+			continue
+		}
+		var keys []id
+		for key := range p.latest {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			r := p.latest[key]
+			fmt.Fprintf(w, "    %s\t%s\t%s\n", r.Test, r.Package, opts.dur(fromSeconds(r.Duration)))
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// tuiViews select which rows are listed and their default order.
+var tuiViews = []string{"duration", "failures", "flakiness"}
+
+// tuiSortKeys are the columns rows can be sorted by.
+var tuiSortKeys = []string{"duration", "name", "failures"}
+
+type tuiRow struct {
+	key      string
+	name     string
+	duration time.Duration
+	tests    int
+	failures int
+	flaky    int
+	status   string
+}
+
+// failRate is the share of failed results among all results of the row.
+func (r tuiRow) failRate(s *stats) float64 {
+	t, ok := s.Tests[r.key]
+	if !ok || t.Passes+t.Failures == 0 {
+		return 0
+	}
+	return float64(t.Failures) / float64(t.Passes+t.Failures)
+}
+
+type tuiLevel int
+
+const (
+	levelPackages tuiLevel = iota
+	levelTests
+	levelOutput
+)
+
+type tui struct {
+	s         *stats
+	opts      options
+	out       *bufio.Writer
+	level     tuiLevel
+	pkg       pkgid
+	test      id
+	view      int
+	sortKey   int
+	reverse   bool
+	query     string
+	searching bool
+	cursor    int
+	offset    int
+	// positions remembers the cursor of the levels above the current one.
+	positions []int
+}
+
+func (u *tui) packageRows() []tuiRow {
+	byPkg := make(map[pkgid]*tuiRow)
+	for _, p := range u.s.Packages {
+		byPkg[p.ID] = &tuiRow{key: p.ID, name: p.ID, duration: p.Duration, status: statusOf(p.Passed)}
+	}
+	for _, t := range u.s.Tests {
+		r, ok := byPkg[t.Package]
+		if !ok {
+			r = &tuiRow{key: t.Package, name: t.Package, status: "?"}
+			byPkg[t.Package] = r
+		}
+		r.tests++
+		r.failures += t.Failures
+		if t.Flaky() {
+			r.flaky++
+		}
+	}
+	var rows []tuiRow
+	for _, r := range byPkg {
+		rows = append(rows, *r)
+	}
+	return rows
+}
+
+func (u *tui) testRows() []tuiRow {
+	var rows []tuiRow
+	for k, t := range u.s.Tests {
+		if t.Package != u.pkg {
+			continue
+		}
+		r := tuiRow{key: k, name: t.Name, duration: t.Duration, tests: t.Passes + t.Failures, failures: t.Failures, status: statusOf(t.Passed)}
+		if t.Flaky() {
+			r.flaky = 1
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func statusOf(passed bool) string {
+	if passed {
+		return "pass"
+	}
+	return "fail"
+}
+
+// rows lists the rows of the current level after applying the view, the
+// search query and the sort order.
+func (u *tui) rows() []tuiRow {
+	var all []tuiRow
+	if u.level == levelPackages {
+		all = u.packageRows()
+	} else {
+		all = u.testRows()
+	}
+	var rows []tuiRow
+	query := strings.ToLower(u.query)
+	for _, r := range all {
+		switch tuiViews[u.view] {
+		case "failures":
+			if r.failures == 0 {
+				continue
+			}
+		case "flakiness":
+			if r.flaky == 0 {
+				continue
+			}
+		}
+		if query != "" && !strings.Contains(strings.ToLower(r.name), query) {
+			continue
+		}
+		rows = append(rows, r)
+	}
+	// Rows come from maps: order them by key first so that rows comparing
+	// equal keep a fixed order under the stable sort.
+	sort.Slice(rows, func(i, j int) bool { return rows[i].key < rows[j].key })
+	less := func(a, b tuiRow) bool {
+		switch tuiSortKeys[u.sortKey] {
+		case "name":
+			return a.name < b.name
+		case "failures":
+			if tuiViews[u.view] == "flakiness" && u.level == levelTests {
+				return a.failRate(u.s) > b.failRate(u.s)
+			}
+			return a.failures > b.failures
+		default:
+			return a.duration > b.duration
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if u.reverse {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+	return rows
+}
+
+func (u *tui) outputLines() []string {
+	var lines []string
+	query := strings.ToLower(u.query)
+	for _, l := range u.s.Outputs[u.test] {
+		if query == "" || strings.Contains(strings.ToLower(l), query) {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func (u *tui) length() int {
+	if u.level == levelOutput {
+		return len(u.outputLines())
+	}
+	return len(u.rows())
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(s) > width {
+		return s[:width]
+	}
+	return s
+}
+
+func (u *tui) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 3 {
+		width, height = 80, 24
+	}
+	page := height - 3
+	if u.cursor < u.offset {
+		u.offset = u.cursor
+	}
+	if u.cursor >= u.offset+page {
+		u.offset = u.cursor - page + 1
+	}
+
+	fmt.Fprint(u.out, clearScreen)
+	var title string
+	switch u.level {
+	case levelPackages:
+		title = "packages"
+	case levelTests:
+		title = u.pkg
+	case levelOutput:
+		title = u.test
+	}
+	header := fmt.Sprintf("%s | view: %s | sort: %s", title, tuiViews[u.view], tuiSortKeys[u.sortKey])
+	if u.reverse {
+		header += " (reversed)"
+	}
+	if u.query != "" || u.searching {
+		header += " | search: " + u.query
+	}
+	fmt.Fprintf(u.out, "\033[7m%-*s\033[0m\r\n", width, truncate(header, width))
+
+	var lines []string
+	switch u.level {
+	case levelOutput:
+		lines = u.outputLines()
+	default:
+		for _, r := range u.rows() {
+			lines = append(lines, fmt.Sprintf("%-4s %10s %5d %4d %4d  %s", r.status, u.opts.dur(r.duration), r.tests, r.failures, r.flaky, r.name))
+		}
+	}
+	switch u.level {
+	case levelPackages:
+		fmt.Fprintf(u.out, "\033[1m%-4s %10s %5s %4s %4s  %s\033[0m\r\n", "stat", "duration", "tests", "fail", "flky", "name")
+	case levelTests:
+		fmt.Fprintf(u.out, "\033[1m%-4s %10s %5s %4s %4s  %s\033[0m\r\n", "stat", "duration", "runs", "fail", "flky", "name")
+	default:
+		fmt.Fprintf(u.out, "\r\n")
+	}
+	for i := u.offset; i < len(lines) && i < u.offset+page; i++ {
+		line := truncate(lines[i], width)
+		if i == u.cursor && u.level != levelOutput {
+			fmt.Fprintf(u.out, "\033[7m%-*s\033[0m\r\n", width, line)
+		} else {
+			fmt.Fprintf(u.out, "%s\r\n", line)
+		}
+	}
+	for i := len(lines) - u.offset; i < page; i++ {
+		fmt.Fprintf(u.out, "\r\n")
+	}
+	help := "q quit  enter open  esc back  / search  s sort  r reverse  v view"
+	if u.searching {
+		help = "type to search  enter keep  esc clear"
+	}
+	fmt.Fprintf(u.out, "\033[7m%-*s\033[0m", width, truncate(help, width))
+	u.out.Flush()
+}
+
+func (u *tui) enter() {
+	rows := u.rows()
+	if u.level == levelOutput || u.cursor >= len(rows) {
+		return
+	}
+	if u.level == levelPackages {
+		u.pkg = rows[u.cursor].key
+	} else {
+		u.test = rows[u.cursor].key
+	}
+	u.positions = append(u.positions, u.cursor)
+	u.level++
+	u.cursor, u.offset, u.query = 0, 0, ""
+}
+
+func (u *tui) back() {
+	if u.level == levelPackages {
+		return
+	}
+	u.level--
+	u.cursor = u.positions[len(u.positions)-1]
+	u.positions = u.positions[:len(u.positions)-1]
+	u.offset, u.query = 0, ""
+}
+
+func (u *tui) move(n int) {
+	u.cursor += n
+	if last := u.length() - 1; u.cursor > last {
+		u.cursor = last
+	}
+	if u.cursor < 0 {
+		u.cursor = 0
+	}
+}
+
+// handle processes one read from the terminal and reports whether to quit.
+func (u *tui) handle(key string) bool {
+	if u.searching {
+		switch key {
+		case "\r":
+			u.searching = false
+		case "\x1b":
+			u.searching, u.query = false, ""
+		case "\x7f", "\b":
+			if len(u.query) > 0 {
+				u.query = u.query[:len(u.query)-1]
+			}
+		default:
+			if len(key) == 1 && key[0] >= ' ' {
+				u.query += key
+			}
+		}
+		u.cursor, u.offset = 0, 0
+		return false
+	}
+	switch key {
+	case "q", "\x03":
+		return true
+	case "\x1b[A", "k":
+		u.move(-1)
+	case "\x1b[B", "j":
+		u.move(1)
+	case "\x1b[5~":
+		u.move(-10)
+	case "\x1b[6~":
+		u.move(10)
+	case "\r", "\x1b[C", "l":
+		u.enter()
+	case "\x1b", "\x7f", "\x1b[D", "h":
+		u.back()
+	case "/":
+		u.searching, u.query = true, ""
+	case "s":
+		u.sortKey = (u.sortKey + 1) % len(tuiSortKeys)
+	case "r":
+		u.reverse = !u.reverse
+	case "v":
+		u.view = (u.view + 1) % len(tuiViews)
+		if tuiViews[u.view] == "duration" {
+			u.sortKey = 0
+		} else {
+			u.sortKey = 2
+		}
+		u.cursor, u.offset = 0, 0
+	}
+	return false
+}
+
+func runTUI(args []string) {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	var opts options
+	fs.StringVar(&opts.durationFormat, "duration-format", "human", "Duration `format` in the lists: "+strings.Join(durationFormats, "|"))
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats tui [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Interactively explores packages, their tests and captured test output.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if !isDurationFormat(opts.durationFormat) {
+		fatalf("tui: -duration-format must be one of `%s`", strings.Join(durationFormats, "`, `"))
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fatal("tui: stdin is not a terminal")
+	}
+	s := newStats()
+	s.CaptureOutput = true
+	s.addFiles(fs.Args())
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fatal(err)
+	}
+	defer term.Restore(fd, state)
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	u := &tui{s: s, opts: opts, out: bufio.NewWriter(os.Stdout)}
+	buf := make([]byte, 16)
+	for {
+		u.draw()
+		n, err := os.Stdin.Read(buf)
+		if err != nil || u.handle(string(buf[:n])) {
+			return
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t0yv0/goteststats/testjson"
+)
+
+// runRange is the set of runs whose trend point falls within a time range,
+// with the outcomes of their tests.
+type runRange struct {
+	since, until time.Time
+	points       map[string]bool
+	tests        map[id]*testOutcomes
+}
+
+func (r *runRange) String() string {
+	return r.since.Format("2006-01-02") + " to " + r.until.Format("2006-01-02")
+}
+
+// runsBetween collects the runs within [since, until], leaving out those of
+// exclude so that ranges sharing a bound do not share runs.
+func (s *stats) runsBetween(since, until time.Time, exclude *runRange) *runRange {
+	rr := &runRange{since: since, until: until, points: make(map[string]bool), tests: make(map[id]*testOutcomes)}
+	for _, p := range s.trend(since, until) {
+		if exclude == nil || !exclude.points[p.key] {
+			rr.points[p.key] = true
+		}
+	}
+	for _, r := range s.Results {
+		if r.Kind == "test" && r.Status != "skip" && rr.points[trendKey(r)] {
+			tallyResult(rr.tests, r)
+		}
+	}
+	return rr
+}
+
+// slowest ranks the tests that passed in the range by mean passing duration,
+// slowest first.
+func (r *runRange) slowest() []*testOutcomes {
+	var out []*testOutcomes
+	for _, t := range r.tests {
+		if t.passes() > 0 {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if di, dj := out[i].meanPassTime(), out[j].meanPassTime(); di != dj {
+			return di > dj
+		}
+		return testjson.TestID(out[i].pkg, out[i].name) < testjson.TestID(out[j].pkg, out[j].name)
+	})
+	return out
+}
+
+// rankChange is a test that entered or left the top slowest: its mean
+// duration in the range it ranks in, and in the other range, where it may
+// not have passed at all.
+type rankChange struct {
+	t     *testOutcomes
+	other *testOutcomes
+}
+
+// slowChanges compares the top slowest tests of two ranges.
+type slowChanges struct {
+	base, head *runRange
+	top        int
+	entered    []rankChange
+	dropped    []rankChange
+	slower     []delta
+	faster     []delta
+}
+
+// whatChanged lists the tests that entered and left the top slowest from
+// base to head, and the tests whose mean passing duration changed the most
+// in each direction, by more than floor.
+func whatChanged(base, head *runRange, top, movers int, floor time.Duration) *slowChanges {
+	c := &slowChanges{base: base, head: head, top: top}
+	baseTop, headTop := base.slowest(), head.slowest()
+	if len(baseTop) > top {
+		baseTop = baseTop[:top]
+	}
+	if len(headTop) > top {
+		headTop = headTop[:top]
+	}
+	inTop := func(ts []*testOutcomes, t *testOutcomes) bool {
+		for _, u := range ts {
+			if u == t {
+				return true
+			}
+		}
+		return false
+	}
+	for _, t := range headTop {
+		b := base.tests[testjson.TestID(t.pkg, t.name)]
+		if b == nil || !inTop(baseTop, b) {
+			c.entered = append(c.entered, rankChange{t: t, other: b})
+		}
+	}
+	for _, t := range baseTop {
+		h := head.tests[testjson.TestID(t.pkg, t.name)]
+		if h == nil || !inTop(headTop, h) {
+			c.dropped = append(c.dropped, rankChange{t: t, other: h})
+		}
+	}
+
+	var deltas []delta
+	for _, t := range head.slowest() {
+		if b, ok := base.tests[testjson.TestID(t.pkg, t.name)]; ok && b.passes() > 0 {
+			deltas = append(deltas, delta{pkg: t.pkg, name: t.name, base: b.meanPassTime(), head: t.meanPassTime()})
+		}
+	}
+	sort.SliceStable(deltas, func(i, j int) bool { return deltas[j].change() < deltas[i].change() })
+	for _, d := range deltas {
+		if d.change() > floor && len(c.slower) < movers {
+			c.slower = append(c.slower, d)
+		}
+	}
+	for i := len(deltas) - 1; i >= 0; i-- {
+		if d := deltas[i]; -d.change() > floor && len(c.faster) < movers {
+			c.faster = append(c.faster, d)
+		}
+	}
+	return c
+}
+
+// passTime formats the mean passing duration of t, or why there is none.
+func (c *slowChanges) passTime(t *testOutcomes, opts options) string {
+	switch {
+	case t == nil:
+		return "not run"
+	case t.passes() == 0:
+		return "failed"
+	}
+	return opts.dur(t.meanPassTime())
+}
+
+// writeMarkdown writes the changes as Markdown sections for pasting into an
+// update, with a line saying so for sections without changes.
+func (c *slowChanges) writeMarkdown(w io.Writer, opts options) {
+	fmt.Fprintf(w, "**Slow tests, %s** (%d runs) vs %s (%d runs)\n\n", c.head, len(c.head.points), c.base, len(c.base.points))
+
+	fmt.Fprintf(w, "### New in the top %d slowest\n\n", c.top)
+	if len(c.entered) == 0 {
+		fmt.Fprintf(w, "None.\n\n")
+	} else {
+		fmt.Fprintf(w, "| Test | Package | Before | Now |\n| --- | --- | ---: | ---: |\n")
+		for _, r := range c.entered {
+			fmt.Fprintf(w, "| `%s` | `%s` | %s | %s |\n", r.t.name, r.t.pkg, c.passTime(r.other, opts), c.passTime(r.t, opts))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	fmt.Fprintf(w, "### Out of the top %d slowest\n\n", c.top)
+	if len(c.dropped) == 0 {
+		fmt.Fprintf(w, "None.\n\n")
+	} else {
+		fmt.Fprintf(w, "| Test | Package | Before | Now |\n| --- | --- | ---: | ---: |\n")
+		for _, r := range c.dropped {
+			fmt.Fprintf(w, "| `%s` | `%s` | %s | %s |\n", r.t.name, r.t.pkg, c.passTime(r.t, opts), c.passTime(r.other, opts))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	for _, section := range []struct {
+		title  string
+		deltas []delta
+	}{{"Biggest slowdowns", c.slower}, {"Biggest speedups", c.faster}} {
+		fmt.Fprintf(w, "### %s\n\n", section.title)
+		if len(section.deltas) == 0 {
+			fmt.Fprintf(w, "None.\n\n")
+			continue
+		}
+		fmt.Fprintf(w, "| Test | Package | Before | Now | Change |\n| --- | --- | ---: | ---: | ---: |\n")
+		for _, d := range section.deltas {
+			fmt.Fprintf(w, "| `%s` | `%s` | %s | %s | %s (%+.0f%%) |\n", d.name, d.pkg, opts.dur(d.base), opts.dur(d.head), signed(opts.dur(d.change())), 100*d.ratio())
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// writeText writes a row per change, starting with entered, dropped, slower
+// or faster.
+func (c *slowChanges) writeText(w io.Writer, opts options) {
+	for _, r := range c.entered {
+		fmt.Fprintf(w, "entered\t%s\t%s\t%s\t%s\n", r.t.name, r.t.pkg, c.passTime(r.other, opts), c.passTime(r.t, opts))
+	}
+	for _, r := range c.dropped {
+		fmt.Fprintf(w, "dropped\t%s\t%s\t%s\t%s\n", r.t.name, r.t.pkg, c.passTime(r.t, opts), c.passTime(r.other, opts))
+	}
+	for _, d := range c.slower {
+		fmt.Fprintf(w, "slower\t%s\t%s\t%s\t%s\t%s\t%+.1f%%\n", d.name, d.pkg, opts.dur(d.base), opts.dur(d.head), signed(opts.dur(d.change())), 100*d.ratio())
+	}
+	for _, d := range c.faster {
+		fmt.Fprintf(w, "faster\t%s\t%s\t%s\t%s\t%s\t%+.1f%%\n", d.name, d.pkg, opts.dur(d.base), opts.dur(d.head), signed(opts.dur(d.change())), 100*d.ratio())
+	}
+}
+
+func whatChangedCommand(args []string) {
+	fs := flag.NewFlagSet("what-changed", flag.ExitOnError)
+	var since, until, baseSince, baseUntil dateFlag
+	fs.Var(&since, "since", "Start `date` of the runs to report on (default: -period before -until)")
+	fs.Var(&until, "until", "End `date` of the runs to report on (default: the latest run)")
+	fs.Var(&baseSince, "base-since", "Start `date` of the runs to compare against (default: -period before -base-until)")
+	fs.Var(&baseUntil, "base-until", "End `date` of the runs to compare against (default: -since)")
+	period := fs.Duration("period", 7*24*time.Hour, "Length of the ranges whose start is not given")
+	top := fs.Int("top", 10, "Number of slowest tests whose membership to compare")
+	movers := fs.Int("movers", 5, "Number of biggest slowdowns and speedups to list")
+	floor := fs.Duration("regression-floor", 100*time.Millisecond, "Ignore changes smaller than this duration")
+	format := fs.String("format", "markdown", "Output format: markdown|text")
+	var opts options
+	fs.StringVar(&opts.durationFormat, "duration-format", "human", "Duration `format` in reports: "+strings.Join(durationFormats, "|"))
+	addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goteststats what-changed [flags] [file1.json ... fileN.json]\n\n")
+		fmt.Fprintf(fs.Output(), "Compares the slowest tests of two ranges of runs, by default the last week\nand the week before: tests that entered and left the top slowest, and the\nbiggest movers both ways.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	switch {
+	case *format != "markdown" && *format != "text":
+		fatal("what-changed: -format must be one of `markdown`, `text`")
+	case !isDurationFormat(opts.durationFormat):
+		fatalf("what-changed: -duration-format must be one of `%s`", strings.Join(durationFormats, "`, `"))
+	case *top < 1:
+		fatal("what-changed: -top must be at least 1")
+	}
+
+	s := newStatsFromFiles(fs.Args(), nil)
+	points := s.trend(time.Time{}, time.Time{})
+	if len(points) == 0 {
+		fatal("what-changed: no test results")
+	}
+	if until.IsZero() {
+		until.Time = points[len(points)-1].time
+	}
+	if since.IsZero() {
+		since.Time = until.Add(-*period)
+	}
+	if baseUntil.IsZero() {
+		baseUntil.Time = since.Time
+	}
+	if baseSince.IsZero() {
+		baseSince.Time = baseUntil.Add(-*period)
+	}
+	head := s.runsBetween(since.Time, until.Time, nil)
+	base := s.runsBetween(baseSince.Time, baseUntil.Time, head)
+	switch {
+	case len(head.points) == 0:
+		fatalf("what-changed: no runs from %s", head)
+	case len(base.points) == 0:
+		fatalf("what-changed: no runs from %s to compare against", base)
+	}
+
+	c := whatChanged(base, head, *top, *movers, *floor)
+	if *format == "text" {
+		c.writeText(os.Stdout, opts)
+		return
+	}
+	c.writeMarkdown(os.Stdout, opts)
+}
@@ -1,3 +1,10 @@
 module github.com/t0yv0/goteststats
 
 go 1.16
+
+require (
+	github.com/klauspost/compress v1.15.15
+	golang.org/x/sys v0.1.0 // indirect
+	golang.org/x/term v0.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,121 @@
+package testjson
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Accumulator aggregates events from many concurrent streams, such as the
+// `go test -json` output of several CI jobs, for a long-running service. It
+// is safe for use by multiple goroutines. Each stream is accumulated on its
+// own, so that runs of the same packages in different jobs do not mix, and
+// streams can be ingested in parallel.
+type Accumulator struct {
+	mu      sync.Mutex
+	streams map[string]*accumulatorStream
+	// summaryTop bounds the failures, slowest tests and flakes listed in
+	// each stream summary of a snapshot.
+	summaryTop int
+}
+
+type accumulatorStream struct {
+	mu sync.Mutex
+	s  *Stats
+}
+
+// Snapshot is a consistent copy of everything accumulated at one instant,
+// which later events do not change.
+type Snapshot struct {
+	Time time.Time
+	// Results lists the package and test results of every stream, by
+	// stream name and then in the order they arrived.
+	Results []Result
+	// Streams summarizes each stream by name.
+	Streams map[string]Summary
+}
+
+// NewAccumulator returns an empty Accumulator.
+func NewAccumulator() *Accumulator {
+	return &Accumulator{streams: make(map[string]*accumulatorStream), summaryTop: 10}
+}
+
+func (a *Accumulator) stream(name string) *accumulatorStream {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st, ok := a.streams[name]
+	if !ok {
+		st = &accumulatorStream{s: NewStats()}
+		st.s.Tags.Run = name
+		a.streams[name] = st
+	}
+	return st
+}
+
+// Add accumulates an event of the named stream.
+func (a *Accumulator) Add(stream string, event RawLine) {
+	st := a.stream(stream)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.Add(event)
+}
+
+// AddReader accumulates the `go test -json` output read from r into the
+// named stream until r ends, skipping lines that are not test events. It
+// returns the number of lines skipped.
+func (a *Accumulator) AddReader(stream string, r io.Reader) (int, error) {
+	st := a.stream(stream)
+	return ReadEvents(r, false, func(event RawLine) {
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		st.s.Add(event)
+	})
+}
+
+// Remove forgets the named stream, such as once a CI job has been reported.
+func (a *Accumulator) Remove(stream string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.streams, stream)
+}
+
+// Snapshot copies the current state of every stream. All streams are locked
+// at once, so that the snapshot reflects a single instant.
+func (a *Accumulator) Snapshot() Snapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var names []string
+	for name := range a.streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		st := a.streams[name]
+		st.mu.Lock()
+		defer st.mu.Unlock()
+	}
+
+	snap := Snapshot{Time: time.Now(), Streams: make(map[string]Summary)}
+	for _, name := range names {
+		s := a.streams[name].s
+		for _, r := range s.Results {
+			snap.Results = append(snap.Results, r.clone())
+		}
+		snap.Streams[name] = s.Summary(a.summaryTop)
+	}
+	return snap
+}
+
+// clone copies a result along with its labels, which the original shares
+// with the Tags of its stream.
+func (r *Result) clone() Result {
+	c := *r
+	if r.Labels != nil {
+		c.Labels = make(map[string]string, len(r.Labels))
+		for k, v := range r.Labels {
+			c.Labels[k] = v
+		}
+	}
+	return c
+}
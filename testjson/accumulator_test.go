@@ -0,0 +1,90 @@
+package testjson
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestAccumulatorConcurrent ingests into several streams while taking
+// snapshots, for running with -race.
+func TestAccumulatorConcurrent(t *testing.T) {
+	const streams, tests = 4, 50
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := NewAccumulator()
+
+	var wg sync.WaitGroup
+	for i := 0; i < streams; i++ {
+		wg.Add(1)
+		go func(stream string) {
+			defer wg.Done()
+			for j := 0; j < tests; j++ {
+				name := fmt.Sprintf("Test%d", j)
+				a.Add(stream, RawLine{Action: "run", Package: "p", Test: name, Time: t0})
+				a.Add(stream, RawLine{Action: "pass", Package: "p", Test: name, Elapsed: 1, Time: t0.Add(time.Second)})
+			}
+		}(fmt.Sprintf("job%d", i))
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			snap := a.Snapshot()
+			for name, sum := range snap.Streams {
+				if sum.Passed > tests {
+					t.Errorf("stream %s passed %d tests, want at most %d", name, sum.Passed, tests)
+				}
+			}
+		}
+	}()
+	wg.Wait()
+	<-done
+
+	snap := a.Snapshot()
+	if len(snap.Streams) != streams {
+		t.Fatalf("snapshot has %d streams, want %d", len(snap.Streams), streams)
+	}
+	if len(snap.Results) != streams*tests {
+		t.Errorf("snapshot has %d results, want %d", len(snap.Results), streams*tests)
+	}
+	for name, sum := range snap.Streams {
+		if sum.Passed != tests {
+			t.Errorf("stream %s passed %d tests, want %d", name, sum.Passed, tests)
+		}
+	}
+	for _, r := range snap.Results {
+		if r.Run == "" {
+			t.Errorf("result of %s is not tagged with its stream", r.Test)
+		}
+	}
+}
+
+// TestAccumulatorSnapshotLabels reads the labels of a snapshot while the
+// stream they came from keeps changing its own, for running with -race.
+func TestAccumulatorSnapshotLabels(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := NewAccumulator()
+	st := a.stream("job")
+	st.s.Tags.Labels = map[string]string{"os": "linux"}
+	a.Add("job", RawLine{Action: "pass", Package: "p", Test: "Test", Elapsed: 1, Time: t0})
+	snap := a.Snapshot()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			st.mu.Lock()
+			st.s.Tags.Labels["os"] = fmt.Sprint(i)
+			st.mu.Unlock()
+			a.Add("job", RawLine{Action: "pass", Package: "p", Test: "Test", Elapsed: 1, Time: t0})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if got := snap.Results[0].Labels["os"]; got != "linux" {
+			t.Errorf("snapshot label os = %q, want %q", got, "linux")
+			break
+		}
+	}
+	<-done
+}
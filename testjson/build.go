@@ -0,0 +1,69 @@
+package testjson
+
+import (
+	"sort"
+	"strings"
+)
+
+// buildOutputLines bounds the compiler or vet output kept per failure.
+const buildOutputLines = 20
+
+// BuildFailure is a package that failed before running any tests. Kind is
+// build, vet or setup.
+type BuildFailure struct {
+	Package string
+	Kind    string
+	Output  []string
+}
+
+// recordBuild collects `build-output` events, which carry an ImportPath
+// rather than a Package and no timestamp, until the failing package refers to
+// them through its FailedBuild field.
+func (s *Stats) recordBuild(line RawLine) {
+	if line.Action != "build-output" {
+		return
+	}
+	out := s.buildOutput[line.ImportPath]
+	if len(out) < buildOutputLines {
+		s.buildOutput[line.ImportPath] = append(out, strings.TrimRight(line.Output, "\n"))
+	}
+}
+
+// recordBuildFailure notes packages that failed before running any tests.
+// Since Go 1.24 the package result names the failed build; older versions
+// only print a `FAIL pkg [build failed]` line.
+func (s *Stats) recordBuildFailure(line RawLine) {
+	switch {
+	case line.Test == "" && line.Action == "fail" && line.FailedBuild != "":
+		output := s.buildOutput[line.FailedBuild]
+		delete(s.buildOutput, line.FailedBuild)
+		kind := "build"
+		for _, l := range output {
+			// vet reports under a `# [pkg]` header, the compiler under `# pkg`.
+			if strings.HasPrefix(l, "# [") {
+				kind = "vet"
+			}
+		}
+		s.BuildFailures[line.Package] = &BuildFailure{Package: line.Package, Kind: kind, Output: output}
+	case line.Action == "output" && s.BuildFailures[line.Package] == nil:
+		out := strings.TrimSpace(line.Output)
+		if !strings.HasPrefix(out, "FAIL") {
+			return
+		}
+		if strings.HasSuffix(out, "[build failed]") {
+			s.BuildFailures[line.Package] = &BuildFailure{Package: line.Package, Kind: "build"}
+		} else if strings.HasSuffix(out, "[setup failed]") {
+			s.BuildFailures[line.Package] = &BuildFailure{Package: line.Package, Kind: "setup"}
+		}
+	}
+}
+
+// BuildFailuresByPackage lists the build failures by package path.
+func (s *Stats) BuildFailuresByPackage() []*BuildFailure {
+	var out []*BuildFailure
+	for _, b := range s.BuildFailures {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Package < out[j].Package })
+	return out
+}
@@ -0,0 +1,99 @@
+package testjson
+
+import "strings"
+
+// pkgRun tracks a package from its first event to its result, so that the
+// results of a cached package, replayed by go test, can be taken back.
+type pkgRun struct {
+	cached bool
+	// Lengths of the results and mismatches when the run began.
+	results    int
+	mismatches int
+	undo       []testUndo
+}
+
+// testUndo holds what a test result replaced.
+type testUndo struct {
+	key  string
+	test *Test
+	skip *Test
+}
+
+func (s *Stats) pkgRunOf(p string) *pkgRun {
+	run, ok := s.pkgRuns[p]
+	if !ok {
+		run = &pkgRun{results: len(s.Results), mismatches: len(s.Mismatches)}
+		s.pkgRuns[p] = run
+	}
+	return run
+}
+
+// recordCached notes the `ok  pkg  (cached)` line go test prints for
+// packages whose results were reused from the build cache.
+func (s *Stats) recordCached(line RawLine) {
+	run := s.pkgRunOf(line.Package)
+	if line.Test != "" || line.Action != "output" {
+		return
+	}
+	out := strings.TrimSpace(line.Output)
+	if strings.HasPrefix(out, "ok") && strings.Contains(out, "(cached)") {
+		run.cached = true
+	}
+}
+
+// noteTestResult remembers the aggregates a test result is about to replace.
+func (s *Stats) noteTestResult(line RawLine, key string) {
+	run := s.pkgRunOf(line.Package)
+	run.undo = append(run.undo, testUndo{key: key, test: s.Tests[key], skip: s.Skips[key]})
+}
+
+// endPkgRun closes the run of a package at its result and reports whether
+// the result should be kept. Unless cached results are included, a cached
+// package is dropped along with the test results it replayed, whose stale
+// durations and the package's near-zero one would skew timings.
+func (s *Stats) endPkgRun(line RawLine) (cached, keep bool) {
+	run := s.pkgRunOf(line.Package)
+	delete(s.pkgRuns, line.Package)
+	s.PackageResults++
+	if !run.cached {
+		return false, true
+	}
+	s.CachedResults++
+	if s.IncludeCached {
+		return true, true
+	}
+	for i := len(run.undo) - 1; i >= 0; i-- {
+		u := run.undo[i]
+		restore(s.Tests, u.key, u.test)
+		restore(s.Skips, u.key, u.skip)
+	}
+	results := s.Results[:run.results]
+	for _, r := range s.Results[run.results:] {
+		if r.Package != line.Package {
+			results = append(results, r)
+			continue
+		}
+		key := r.Package
+		if r.Test != "" {
+			key = TestID(r.Package, r.Test)
+		}
+		s.attempts[key]--
+	}
+	s.Results = results
+	mismatches := s.Mismatches[:run.mismatches]
+	for _, m := range s.Mismatches[run.mismatches:] {
+		if m.Package != line.Package {
+			mismatches = append(mismatches, m)
+		}
+	}
+	s.Mismatches = mismatches
+	return true, false
+}
+
+func restore(m map[string]*Test, key string, t *Test) {
+	if t == nil {
+		delete(m, key)
+	} else {
+		m[key] = t
+	}
+}
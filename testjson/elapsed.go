@@ -0,0 +1,48 @@
+package testjson
+
+import "time"
+
+// Disagreements between a reported Elapsed and the time measured between
+// events are flagged when they exceed both of these.
+const (
+	mismatchMinDiff  = 100 * time.Millisecond
+	mismatchMinRatio = 0.2
+)
+
+// Mismatch is a result whose Elapsed disagrees with the time measured between
+// its events.
+type Mismatch struct {
+	Package  string
+	Test     string
+	Elapsed  time.Duration
+	Measured time.Duration
+}
+
+// Diff is how far apart the two durations are.
+func (m Mismatch) Diff() time.Duration {
+	if m.Measured > m.Elapsed {
+		return m.Measured - m.Elapsed
+	}
+	return m.Elapsed - m.Measured
+}
+
+// durationOf returns the duration of a package or test event. Terminal events
+// without an Elapsed time, as from interrupted runs, get the time measured
+// since the recorded start instead. When both are known and disagree
+// significantly the event is recorded as a mismatch.
+func (s *Stats) durationOf(line RawLine, starts map[string]time.Time, key string) time.Duration {
+	elapsed := time.Duration(line.Elapsed * float64(time.Second))
+	start, ok := starts[key]
+	if !ok || !IsTerminal(line.Action) || start.After(line.Time) {
+		return elapsed
+	}
+	measured := line.Time.Sub(start)
+	if elapsed == 0 {
+		return measured
+	}
+	m := Mismatch{Package: line.Package, Test: line.Test, Elapsed: elapsed, Measured: measured}
+	if m.Diff() > mismatchMinDiff && float64(m.Diff()) > mismatchMinRatio*float64(elapsed) {
+		s.Mismatches = append(s.Mismatches, m)
+	}
+	return elapsed
+}
@@ -0,0 +1,80 @@
+package testjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RawLine is an event of `go test -json`, or of the build output it
+// interleaves since Go 1.24.
+type RawLine struct {
+	Action      string    `json:"Action,omitempty"`
+	Package     string    `json:"Package,omitempty"`
+	Test        string    `json:"Test,omitempty"`
+	Output      string    `json:"Output,omitempty"`
+	Time        time.Time `json:"Time"`
+	Elapsed     float64   `json:"Elapsed,omitempty"`
+	ImportPath  string    `json:"ImportPath,omitempty"`
+	FailedBuild string    `json:"FailedBuild,omitempty"`
+}
+
+// MarshalJSON encodes the event the way `go test -json` does, leaving out
+// empty fields, including the timestamp of build events.
+func (l RawLine) MarshalJSON() ([]byte, error) {
+	type plain RawLine
+	var t *time.Time
+	if !l.Time.IsZero() {
+		t = &l.Time
+	}
+	return json.Marshal(struct {
+		Time *time.Time `json:"Time,omitempty"`
+		plain
+	}{t, plain(l)})
+}
+
+// IsTerminal reports whether action ends a package or test.
+func IsTerminal(action string) bool {
+	return action == "pass" || action == "fail" || action == "skip"
+}
+
+// TestID is the key of a test in the maps of Stats.
+func TestID(pkg, name string) string {
+	return fmt.Sprintf("%s#%s", pkg, name)
+}
+
+// ReadEvents parses a `go test -json` stream, passing each event to fn.
+// Lines that are not JSON events, such as stray stderr output captured
+// alongside, are skipped and counted unless strict is set.
+func ReadEvents(in io.Reader, strict bool, fn func(RawLine)) (skipped int, err error) {
+	// A bufio.Reader rather than a bufio.Scanner, whose token limit would
+	// reject tests that print very long lines.
+	r := bufio.NewReader(in)
+
+	var time0 time.Time
+
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return skipped, err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var rawLine RawLine
+			if jsonErr := json.Unmarshal(line, &rawLine); jsonErr != nil {
+				if strict {
+					return skipped, fmt.Errorf("line %d: %v", n, jsonErr)
+				}
+				skipped++
+			} else if rawLine.Time.After(time0) || rawLine.ImportPath != "" {
+				// Build events carry no timestamp.
+				fn(rawLine)
+			}
+		}
+		if err == io.EOF {
+			return skipped, nil
+		}
+	}
+}
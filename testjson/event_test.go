@@ -0,0 +1,43 @@
+package testjson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadEventsLongLines(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	long := strings.Repeat("x", 5<<20) + "\n"
+	var in strings.Builder
+	for _, ev := range []RawLine{
+		{Action: "run", Package: "p", Test: "TestLong", Time: t0},
+		{Action: "output", Package: "p", Test: "TestLong", Output: long, Time: t0},
+		{Action: "pass", Package: "p", Test: "TestLong", Elapsed: 1, Time: t0.Add(time.Second)},
+	} {
+		b, err := json.Marshal(ev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in.Write(b)
+		in.WriteString("\n")
+	}
+
+	var events []RawLine
+	skipped, err := ReadEvents(strings.NewReader(in.String()), true, func(ev RawLine) {
+		events = append(events, ev)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 0 {
+		t.Errorf("skipped %d lines, want 0", skipped)
+	}
+	if len(events) != 3 {
+		t.Fatalf("read %d events, want 3", len(events))
+	}
+	if events[1].Output != long {
+		t.Errorf("output of %d bytes, want %d", len(events[1].Output), len(long))
+	}
+}
@@ -0,0 +1,83 @@
+package testjson
+
+import "time"
+
+// ResultSchema versions the records written by `-format jsonl`. It changes
+// whenever a field is removed or changes meaning; new fields may be added
+// without a new version.
+const ResultSchema = "goteststats/result/v1"
+
+// StatusNoTests is the status of packages without test files, which go test
+// reports with a package-level skip action.
+const StatusNoTests = "no-tests"
+
+// Result is one package or test outcome as ingested, kept for export. Unlike
+// the Test and Package aggregates, every rerun has its own result.
+type Result struct {
+	Schema   string    `json:"schema"`
+	Kind     string    `json:"kind"`
+	Package  string    `json:"package"`
+	Test     string    `json:"test,omitempty"`
+	Status   string    `json:"status"`
+	Duration float64   `json:"duration_seconds"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	// Attempt counts the results for the same package or test so far,
+	// starting at 1, so reruns of a failure have attempts above 1.
+	Attempt int    `json:"attempt"`
+	Run     string `json:"run"`
+	// Commit, Branch and CommitTime identify the commit tested, when runs
+	// are tagged with -git or -commit.
+	Commit     string     `json:"commit,omitempty"`
+	Branch     string     `json:"branch,omitempty"`
+	CommitTime *time.Time `json:"commit_time,omitempty"`
+	// Labels are the dimensions, such as OS or architecture, the run was
+	// labeled with.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Tags identify the run whose events are being added, and are copied into
+// its results.
+type Tags struct {
+	Run        string
+	Commit     string
+	Branch     string
+	CommitTime time.Time
+	Labels     map[string]string
+}
+
+// recordResult keeps the outcome of a terminal package or test event.
+func (s *Stats) recordResult(line RawLine, start time.Time, duration time.Duration) {
+	kind, key := "package", line.Package
+	if line.Test != "" {
+		kind, key = "test", TestID(line.Package, line.Test)
+	}
+	status := line.Action
+	if kind == "package" && line.Action == "skip" {
+		status = StatusNoTests
+	}
+	s.attempts[key]++
+	r := &Result{
+		Schema:   ResultSchema,
+		Kind:     kind,
+		Package:  line.Package,
+		Test:     line.Test,
+		Status:   status,
+		Duration: duration.Seconds(),
+		Start:    start,
+		End:      line.Time,
+		Attempt:  s.attempts[key],
+		Run:      s.Tags.Run,
+		Commit:   s.Tags.Commit,
+		Branch:   s.Tags.Branch,
+		Labels:   s.Tags.Labels,
+	}
+	if !s.Tags.CommitTime.IsZero() {
+		t := s.Tags.CommitTime
+		r.CommitTime = &t
+	}
+	s.Results = append(s.Results, r)
+	if s.OnResult != nil {
+		s.OnResult(r)
+	}
+}
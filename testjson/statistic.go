@@ -0,0 +1,56 @@
+package testjson
+
+import (
+	"fmt"
+	"io"
+)
+
+// Statistic is a statistic computed in the same single pass over the events
+// as the built-in ones, for programs embedding goteststats to add their own.
+// Events reach Accumulate as they are added to Stats, which goteststats does
+// after -pkg-filter, -test-filter and -redact apply, including those of
+// packages go test reused from its cache.
+type Statistic interface {
+	// Name is the value of `-statistic` selecting the statistic.
+	Name() string
+	Accumulate(event RawLine)
+	// Report writes the statistic in the output format, such as `text`.
+	Report(w io.Writer, format string) error
+}
+
+// registeredStatistics holds a constructor per registered statistic, in
+// registration order. Every Stats gets its own instances.
+var registeredStatistics []func() Statistic
+
+// RegisterStatistic makes a statistic available to every Stats created
+// afterwards, and to `-statistic`. Like database/sql.Register, it is meant
+// to be called from init functions and panics if the name is already taken.
+func RegisterStatistic(newStatistic func() Statistic) {
+	name := newStatistic().Name()
+	for _, s := range StatisticNames() {
+		if s == name {
+			panic(fmt.Sprintf("RegisterStatistic: statistic %q already registered", name))
+		}
+	}
+	registeredStatistics = append(registeredStatistics, newStatistic)
+}
+
+// StatisticNames lists the registered statistics in registration order.
+func StatisticNames() []string {
+	var names []string
+	for _, newStatistic := range registeredStatistics {
+		names = append(names, newStatistic().Name())
+	}
+	return names
+}
+
+// Statistic returns the instance of the registered statistic named name, if
+// any.
+func (s *Stats) Statistic(name string) Statistic {
+	for _, st := range s.statistics {
+		if st.Name() == name {
+			return st
+		}
+	}
+	return nil
+}
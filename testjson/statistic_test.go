@@ -0,0 +1,60 @@
+package testjson
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// failCount counts the failed tests it is given.
+type failCount struct {
+	fails int
+}
+
+func (c *failCount) Name() string { return "fail-count" }
+
+func (c *failCount) Accumulate(event RawLine) {
+	if event.Test != "" && event.Action == "fail" {
+		c.fails++
+	}
+}
+
+func (c *failCount) Report(w io.Writer, format string) error {
+	_, err := fmt.Fprintf(w, "fail-count\t%d\n", c.fails)
+	return err
+}
+
+func TestRegisterStatistic(t *testing.T) {
+	RegisterStatistic(func() Statistic { return &failCount{} })
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewStats()
+	for _, ev := range []RawLine{
+		{Action: "run", Package: "p", Test: "TestA", Time: t0},
+		{Action: "fail", Package: "p", Test: "TestA", Elapsed: 1, Time: t0.Add(time.Second)},
+		{Action: "run", Package: "p", Test: "TestB", Time: t0},
+		{Action: "pass", Package: "p", Test: "TestB", Elapsed: 1, Time: t0.Add(time.Second)},
+		{Action: "fail", Package: "p", Elapsed: 2, Time: t0.Add(2 * time.Second)},
+	} {
+		s.Add(ev)
+	}
+
+	st, ok := s.Statistic("fail-count").(*failCount)
+	if !ok {
+		t.Fatalf("statistic fail-count not found among %v", StatisticNames())
+	}
+	if st.fails != 1 {
+		t.Errorf("counted %d failures, want 1", st.fails)
+	}
+	if NewStats().Statistic("fail-count") == st {
+		t.Errorf("statistics share an instance")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering fail-count twice did not panic")
+		}
+	}()
+	RegisterStatistic(func() Statistic { return &failCount{} })
+}
@@ -0,0 +1,250 @@
+// Package testjson aggregates the events of `go test -json` into package and
+// test results, the model goteststats computes its statistics from.
+package testjson
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Test is the latest result of a test, with the passes and failures of all
+// its reruns.
+type Test struct {
+	Package  string
+	Name     string
+	Duration time.Duration
+	Passed   bool
+	Passes   int
+	Failures int
+	Start    time.Time
+	End      time.Time
+}
+
+// Flaky reports whether the test both passed and failed across the ingested
+// results, for example when a CI job reruns failures.
+func (t *Test) Flaky() bool {
+	return t.Passes > 0 && t.Failures > 0
+}
+
+// Package is the latest result of a package.
+type Package struct {
+	ID       string
+	Duration time.Duration
+	Passed   bool
+	Cached   bool
+	// NoTests is set for packages without test files, which go test skips.
+	NoTests bool
+	Start   time.Time
+	End     time.Time
+}
+
+// Stats accumulates events into results. It is not safe for concurrent use;
+// see Accumulator for that.
+type Stats struct {
+	// Packages and Tests hold the latest result of every package and test
+	// that passed or failed, keyed by package path and TestID.
+	Packages map[string]*Package
+	Tests    map[string]*Test
+	// NoTestPackages holds the packages without test files, kept apart
+	// from Packages so that they do not count towards package totals.
+	NoTestPackages map[string]*Package
+	Skips          map[string]*Test
+
+	// Mismatches lists results whose Elapsed disagrees with their
+	// timestamps.
+	Mismatches []Mismatch
+
+	// Results of cached packages are dropped unless IncludeCached is set.
+	// PackageResults counts the package results, CachedResults those of
+	// cached packages.
+	IncludeCached  bool
+	PackageResults int
+	CachedResults  int
+
+	// Results lists every package and test outcome, tagged with Tags.
+	Results []*Result
+	Tags    Tags
+	// OnResult, when set, is called with every result as it is recorded.
+	OnResult func(*Result)
+
+	// Outputs holds the output lines of each test when CaptureOutput is set.
+	CaptureOutput bool
+	Outputs       map[string][]string
+
+	// BuildFailures holds the packages that failed before running tests.
+	BuildFailures map[string]*BuildFailure
+	buildOutput   map[string][]string
+
+	// Start times of packages and tests, keyed like the maps above,
+	// recorded until their terminal event arrives.
+	pkgStarts  map[string]time.Time
+	testStarts map[string]time.Time
+
+	// pkgRuns tracks packages until their result; attempts counts the
+	// results per package and test.
+	pkgRuns  map[string]*pkgRun
+	attempts map[string]int
+
+	// statistics holds an instance of every registered Statistic.
+	statistics []Statistic
+}
+
+// NewStats returns empty statistics, with an instance of every registered
+// Statistic.
+func NewStats() *Stats {
+	s := &Stats{
+		Packages:       make(map[string]*Package),
+		NoTestPackages: make(map[string]*Package),
+		Tests:          make(map[string]*Test),
+		Skips:          make(map[string]*Test),
+		Outputs:        make(map[string][]string),
+		BuildFailures:  make(map[string]*BuildFailure),
+		buildOutput:    make(map[string][]string),
+		pkgStarts:      make(map[string]time.Time),
+		testStarts:     make(map[string]time.Time),
+		pkgRuns:        make(map[string]*pkgRun),
+		attempts:       make(map[string]int),
+	}
+	for _, newStatistic := range registeredStatistics {
+		s.statistics = append(s.statistics, newStatistic())
+	}
+	return s
+}
+
+// Add accumulates a single event. Events without a time, package or action
+// are ignored, except for build events.
+func (s *Stats) Add(line RawLine) {
+	if line.ImportPath != "" && line.Package == "" {
+		s.recordBuild(line)
+		for _, st := range s.statistics {
+			st.Accumulate(line)
+		}
+		return
+	}
+	var time0 time.Time
+	if !line.Time.After(time0) || line.Package == "" || line.Action == "" {
+		return
+	}
+	for _, st := range s.statistics {
+		st.Accumulate(line)
+	}
+	s.recordBuildFailure(line)
+	s.recordCached(line)
+	if _, ok := s.pkgStarts[line.Package]; !ok {
+		s.pkgStarts[line.Package] = line.Time
+	}
+	if line.Test != "" {
+		key := TestID(line.Package, line.Test)
+		switch line.Action {
+		case "run", "cont":
+			// Parallel tests pause after starting; Go times them from
+			// when they continue.
+			s.testStarts[key] = line.Time
+		case "output":
+			if s.CaptureOutput {
+				s.Outputs[key] = append(s.Outputs[key], strings.TrimRight(line.Output, "\n"))
+			}
+		}
+		t := &Test{
+			Package:  line.Package,
+			Name:     line.Test,
+			Duration: s.durationOf(line, s.testStarts, key),
+			End:      line.Time,
+		}
+		t.Start = startOf(s.testStarts, key, t.End, t.Duration)
+		if IsTerminal(line.Action) {
+			delete(s.testStarts, key)
+			s.recordResult(line, t.Start, t.Duration)
+		}
+		if prev, ok := s.Tests[key]; ok {
+			t.Passes, t.Failures = prev.Passes, prev.Failures
+		}
+		if IsTerminal(line.Action) {
+			s.noteTestResult(line, key)
+		}
+		switch line.Action {
+		case "pass":
+			t.Passed = true
+			t.Passes++
+			s.Tests[key] = t
+		case "fail":
+			t.Passed = false
+			t.Failures++
+			s.Tests[key] = t
+		case "skip":
+			s.Skips[key] = t
+		}
+	} else {
+		if line.Action == "start" {
+			s.pkgStarts[line.Package] = line.Time
+		}
+		p := &Package{
+			ID:       line.Package,
+			Duration: s.durationOf(line, s.pkgStarts, line.Package),
+			End:      line.Time,
+		}
+		p.Start = startOf(s.pkgStarts, line.Package, p.End, p.Duration)
+		if IsTerminal(line.Action) {
+			delete(s.pkgStarts, line.Package)
+			var keep bool
+			if p.Cached, keep = s.endPkgRun(line); !keep {
+				return
+			}
+			s.recordResult(line, p.Start, p.Duration)
+		}
+		switch line.Action {
+		case "pass":
+			p.Passed = true
+			s.Packages[line.Package] = p
+			delete(s.NoTestPackages, line.Package)
+		case "fail":
+			s.Packages[line.Package] = p
+			delete(s.NoTestPackages, line.Package)
+		case "skip":
+			p.Passed, p.NoTests = true, true
+			s.NoTestPackages[line.Package] = p
+			delete(s.Packages, line.Package)
+		}
+	}
+}
+
+// startOf returns the recorded start time for key, falling back to the end
+// time minus the elapsed time when no start event was seen.
+func startOf(starts map[string]time.Time, key string, end time.Time, elapsed time.Duration) time.Time {
+	if t, ok := starts[key]; ok && !t.After(end) {
+		return t
+	}
+	return end.Add(-elapsed)
+}
+
+// TestsByDuration lists the tests that passed or failed, slowest first.
+func (s *Stats) TestsByDuration() []*Test {
+	var out []*Test
+	for _, t := range s.Tests {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Duration != out[j].Duration {
+			return out[j].Duration < out[i].Duration
+		}
+		return TestID(out[i].Package, out[i].Name) < TestID(out[j].Package, out[j].Name)
+	})
+	return out
+}
+
+// PackagesByDuration lists the packages that passed or failed, slowest
+// first.
+func (s *Stats) PackagesByDuration() []*Package {
+	var out []*Package
+	for _, p := range s.Packages {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Duration != out[j].Duration {
+			return out[j].Duration < out[i].Duration
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
@@ -0,0 +1,63 @@
+package testjson
+
+import "time"
+
+// TestSummary is the view of a test in a Summary.
+type TestSummary struct {
+	Package  string
+	Name     string
+	Duration time.Duration
+	Passed   bool
+}
+
+// Summary aggregates a run into totals plus notable tests, as used by report
+// templates.
+type Summary struct {
+	Tests    int
+	Passed   int
+	Failed   int
+	Skipped  int
+	Flaky    int
+	Packages int
+	// NoTestPackages counts the packages without test files, which are
+	// not included in Packages.
+	NoTestPackages int
+	// Cached counts the package results go test reused from its cache, out
+	// of PackageResults.
+	Cached         int
+	PackageResults int
+	Duration       time.Duration
+	Failures       []TestSummary
+	Slowest        []TestSummary
+	Flakes         []TestSummary
+	// BuildFailures lists packages that failed to build or vet, which
+	// report no test results and so are not counted as failed tests.
+	BuildFailures []string
+}
+
+// Summary summarizes the statistics, listing the top slowest tests.
+func (s *Stats) Summary(top int) Summary {
+	sum := Summary{Packages: len(s.Packages), NoTestPackages: len(s.NoTestPackages), Skipped: len(s.Skips), Cached: s.CachedResults, PackageResults: s.PackageResults}
+	for _, b := range s.BuildFailuresByPackage() {
+		sum.BuildFailures = append(sum.BuildFailures, b.Package)
+	}
+	for _, t := range s.TestsByDuration() {
+		ts := TestSummary{Package: t.Package, Name: t.Name, Duration: t.Duration, Passed: t.Passed}
+		sum.Tests++
+		sum.Duration += t.Duration
+		if t.Passed {
+			sum.Passed++
+		} else {
+			sum.Failed++
+			sum.Failures = append(sum.Failures, ts)
+		}
+		if t.Flaky() {
+			sum.Flaky++
+			sum.Flakes = append(sum.Flakes, ts)
+		}
+		if len(sum.Slowest) < top {
+			sum.Slowest = append(sum.Slowest, ts)
+		}
+	}
+	return sum
+}